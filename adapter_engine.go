@@ -1,20 +1,38 @@
 package kapi
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/textproto"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var errType = reflect.TypeOf(new(error)).Elem()
+var contextType = reflect.TypeOf(new(context.Context)).Elem()
 var byteArrType = reflect.TypeOf([]byte{})
 
 type DecodedHandlerFunction struct {
-	structType reflect.Type
+	handlerType reflect.Type
+	structType  reflect.Type
+
+	// receivesContext is true when the handler receives a context.Context
+	// as its first argument instead of the framework specific type
+	receivesContext bool
+
+	// hasGeneratedDecoder is true when the args struct implements
+	// the RequestDecoder interface generated by `kapi gen`
+	hasGeneratedDecoder bool
+
+	// responseType is nil unless the handler
+	// returns a value besides the error
+	responseType reflect.Type
+	responseInfo *responseInfo
 
 	bodyContentType string
 	bodyInfo        *tagInfo
@@ -22,217 +40,562 @@ type DecodedHandlerFunction struct {
 	pathParams    map[string]tagInfo
 	headerParams  map[string]tagInfo
 	queryParams   map[string]tagInfo
+	cookieParams  map[string]tagInfo
 	contextValues map[string]tagInfo
+	requestInfo   map[string]tagInfo
+
+	// These are the fields tagged with `header:"*"` or `query:"*"`
+	// optionally with a prefix before the `*`
+	headerCatchAll []tagInfo
+	queryCatchAll  []tagInfo
+
+	queryDeepObjects []tagInfo
+
+	// decoders contains one decoder per field of the args struct
+	decoders []fieldDecoder
+
+	// argsPool reuses the args structs between requests, see AcquireArgs
+	argsPool *sync.Pool
+
+	errorHandler   ErrorHandler
+	trustedProxies []*net.IPNet
+
+	// registry is nil unless the WithRegistry option was used
+	registry    *Registry
+	operationID string
+}
+
+// DecodeHandlerFunction works as TryDecodeHandlerFunction
+// but stops the program with a fatal error if the handler is invalid.
+func DecodeHandlerFunction(fnType reflect.Type, expectedArgTypes []reflect.Type, opts ...Option) DecodedHandlerFunction {
+	funcInfo, err := TryDecodeHandlerFunction(fnType, expectedArgTypes, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return funcInfo
 }
 
-func DecodeHandlerFunction(fnType reflect.Type, expectedArgTypes []reflect.Type) DecodedHandlerFunction {
+// TryDecodeHandlerFunction parses the type of the user handler and
+// caches the information required for decoding the requests later.
+//
+// The expectedArgTypes are the types of the arguments the handler must
+// receive before the args struct, which should always be the last argument.
+//
+// If the handler is invalid a *HandlerError is returned
+// listing every problem found on the handler and its args struct.
+func TryDecodeHandlerFunction(fnType reflect.Type, expectedArgTypes []reflect.Type, opts ...Option) (DecodedHandlerFunction, error) {
 	if len(expectedArgTypes) == 0 {
-		log.Fatal("adapter code error: the expected list of args for the handler must not be an empty list!")
+		return DecodedHandlerFunction{}, newHandlerError(fnType, []string{
+			"adapter code error: the expected list of args for the handler must not be an empty list!",
+		})
 	}
 
-	if fnType.Kind() != reflect.Func {
-		log.Fatal("adapt's argument must be a function!")
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return DecodedHandlerFunction{}, newHandlerError(fnType, []string{
+			"adapt's argument must be a function!",
+		})
 	}
 
-	if fnType.NumIn() != 2 {
-		log.Fatal("received function must have 2 arguments!")
+	cfg := newConfig(opts)
+	problems := cfg.problems
+	if fnType.NumIn() != len(expectedArgTypes)+1 {
+		problems = append(problems, fmt.Sprintf("received function must have %d arguments!", len(expectedArgTypes)+1))
 	}
 
-	if fnType.In(0) != expectedArgTypes[0] {
-		log.Fatalf("first argument must be of type %v!", expectedArgTypes[0])
+	// Handlers can receive a context.Context instead of the framework specific
+	// first argument, allowing the same handler to be used with any adapter:
+	receivesContext := len(expectedArgTypes) == 1 && fnType.NumIn() > 0 && fnType.In(0) == contextType
+	for i, expectedType := range expectedArgTypes {
+		if receivesContext {
+			break
+		}
+		if i < fnType.NumIn() && fnType.In(i) != expectedType {
+			problems = append(problems, fmt.Sprintf("argument %d must be of type %v or context.Context!", i+1, expectedType))
+		}
 	}
 
-	if fnType.NumOut() != 1 {
-		log.Fatal("received function must have a single return value!")
+	var responseType reflect.Type
+	switch fnType.NumOut() {
+	case 1, 2:
+		if fnType.Out(fnType.NumOut()-1) != errType {
+			problems = append(problems, "last return value must be of type error")
+		}
+		if fnType.NumOut() == 2 {
+			responseType = fnType.Out(0)
+		}
+	default:
+		problems = append(problems, "received function must return either a single error or a value and an error!")
 	}
 
-	if fnType.Out(0) != errType {
-		log.Fatal("first return value must be of type error")
+	var structType reflect.Type
+	if fnType.NumIn() > 0 {
+		structType = fnType.In(fnType.NumIn() - 1)
+	}
+	if structType == nil || structType.Kind() != reflect.Struct {
+		problems = append(problems, "the last argument must be a struct!")
+		return DecodedHandlerFunction{}, newHandlerError(fnType, problems)
 	}
 
-	structType := fnType.In(1)
-	if structType.Kind() != reflect.Struct {
-		log.Fatal("second argument must a struct!")
+	bodyContentType, bodyInfo, err := getBodyInfo(structType)
+	if err != nil {
+		problems = append(problems, err.Error())
 	}
 
-	bodyContentType, bodyInfo := getBodyInfo(structType)
-	pathParams, headerParams, queryParams, contextValues := getTagNames(structType)
-	return DecodedHandlerFunction{
-		structType:      structType,
-		bodyContentType: bodyContentType,
-		bodyInfo:        bodyInfo,
-		pathParams:      pathParams,
-		headerParams:    headerParams,
-		queryParams:     queryParams,
-		contextValues:   contextValues,
+	responseInfo, responseProblems := getResponseInfo(responseType)
+	problems = append(problems, responseProblems...)
+
+	params, tagProblems := getTagNames(structType)
+	problems = append(problems, tagProblems...)
+
+	hasGeneratedDecoder, decoderProblems := checkGeneratedDecoder(structType)
+	problems = append(problems, decoderProblems...)
+
+	if len(problems) > 0 {
+		return DecodedHandlerFunction{}, newHandlerError(fnType, problems)
+	}
+
+	headerCatchAll := extractCatchAllParams(params["header"])
+	queryCatchAll := extractCatchAllParams(params["query"])
+	queryDeepObjects := extractDeepObjectParams(params["query"])
+
+	for key, info := range params["context"] {
+		info.contextKey = key
+		if typedKey, found := cfg.contextKeys[key]; found {
+			info.contextKey = typedKey
+		}
+		params["context"][key] = info
 	}
+
+	decoders := compileDecoders(cfg, bodyContentType, bodyInfo, params, headerCatchAll, queryCatchAll, queryDeepObjects)
+
+	return DecodedHandlerFunction{
+		handlerType:         fnType,
+		structType:          structType,
+		receivesContext:     receivesContext,
+		hasGeneratedDecoder: hasGeneratedDecoder,
+		responseType:        responseType,
+		responseInfo:        responseInfo,
+		bodyContentType:     bodyContentType,
+		bodyInfo:            bodyInfo,
+		pathParams:          params["path"],
+		headerParams:        params["header"],
+		queryParams:         params["query"],
+		cookieParams:        params["cookie"],
+		contextValues:       params["context"],
+		requestInfo:         params["request"],
+		headerCatchAll:      headerCatchAll,
+		queryCatchAll:       queryCatchAll,
+		queryDeepObjects:    queryDeepObjects,
+		decoders:            decoders,
+		argsPool: &sync.Pool{
+			New: func() interface{} {
+				return reflect.New(structType).Interface()
+			},
+		},
+		errorHandler:   cfg.errorHandler,
+		trustedProxies: cfg.trustedProxies,
+		registry:       cfg.registry,
+		operationID:    cfg.operationID,
+	}, nil
+}
+
+// AcquireArgs returns a pointer to an empty args struct from a pool, so the
+// adapters can avoid allocating a new struct per request. It should be
+// returned with ReleaseArgs once the handler returns.
+//
+// Since the handlers receive the args struct by value it can be safely reused,
+// the values stored on it, e.g. maps and slices, are allocated per request
+// and are never reused.
+func (d DecodedHandlerFunction) AcquireArgs() reflect.Value {
+	return reflect.ValueOf(d.argsPool.Get())
+}
+
+// ReleaseArgs clears the args struct and puts it back on the pool
+func (d DecodedHandlerFunction) ReleaseArgs(args reflect.Value) {
+	args.Elem().Set(reflect.Zero(d.structType))
+	d.argsPool.Put(args.Interface())
 }
 
+// ReceivesContext reports whether the handler receives a context.Context
+// as its first argument instead of the framework specific type, in which
+// case the adapters should pass the value returned by `RequestAdapter.GetContext()`.
+func (d DecodedHandlerFunction) ReceivesContext() bool {
+	return d.receivesContext
+}
+
+// UnmarshalRequestAsStruct allocates a new args struct and fills it with
+// the values of the request, it returns a pointer to the struct.
 func UnmarshalRequestAsStruct(request RequestAdapter, funcInfo DecodedHandlerFunction) (inputStruct reflect.Value, _ error) {
 	inputStruct = reflect.New(funcInfo.structType)
-	if funcInfo.bodyInfo != nil {
-		var param reflect.Value
-		switch funcInfo.bodyContentType {
-		case "application/json":
-			param = reflect.New(funcInfo.structType.Field(funcInfo.bodyInfo.Idx).Type)
-			err := json.Unmarshal(request.GetBody(), param.Interface())
-			if err != nil {
-				return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-					"could not parse body as JSON: %s", err.Error(),
-				))
-			}
-			// Dereference the pointer:
-			param = param.Elem()
-
-		case "application/octet-stream":
-			param = reflect.ValueOf(request.GetBody())
-		default:
-			panic(fmt.Sprintf(
-				"code error: unexpected mimetype received: '%s', for the Body field",
-				funcInfo.bodyContentType,
-			))
-		}
+	err := DecodeRequestInto(request, funcInfo, inputStruct.Elem())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return inputStruct, nil
+}
 
-		inputStruct.Elem().Field(funcInfo.bodyInfo.Idx).Set(param)
+// DecodeRequestInto works as UnmarshalRequestAsStruct but fills an existing
+// args struct, which must be addressable and should be empty, e.g. one
+// acquired with `AcquireArgs`.
+func DecodeRequestInto(request RequestAdapter, funcInfo DecodedHandlerFunction, args reflect.Value) error {
+	if funcInfo.hasGeneratedDecoder {
+		return args.Addr().Interface().(RequestDecoder).DecodeRequest(request, funcInfo)
 	}
 
-	for key, info := range funcInfo.pathParams {
-		param := request.GetPathParam(key)
-		if param == "" {
-			// Path params are always required, that's why we won't
-			// check the Default and Required fields here
-			return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-				"path param '%s' is empty", key,
-			))
+	for _, decode := range funcInfo.decoders {
+		err := decode(request, args)
+		if err != nil {
+			return err
 		}
+	}
 
-		v, err := decodeType(info.Kind, param)
-		if err != nil {
-			return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-				"could not convert path param to %s: %s", reflect.Int, err.Error(),
-			))
+	return nil
+}
+
+// extractCatchAllParams removes the catch-all params from the map
+// since they are decoded differently from the other params.
+func extractCatchAllParams(params map[string]tagInfo) (catchAll []tagInfo) {
+	for _, key := range sortedKeys(params) {
+		if !params[key].CatchAll {
+			continue
 		}
 
-		inputStruct.Elem().Field(info.Idx).Set(v)
+		catchAll = append(catchAll, params[key])
+		delete(params, key)
 	}
-	for key, info := range funcInfo.headerParams {
-		param := request.GetHeaderParam(key)
-		if param == "" {
-			param = info.Default
-		}
-		if param == "" {
-			if info.Required {
-				return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-					"required header param '%s' is empty", key,
-				))
-			}
+	return catchAll
+}
 
+// extractDeepObjectParams removes the deepObject params from the map
+// since they are decoded differently from the other params.
+func extractDeepObjectParams(params map[string]tagInfo) (deepObjects []tagInfo) {
+	for _, key := range sortedKeys(params) {
+		if params[key].deepObject == nil {
 			continue
 		}
 
-		v, err := decodeType(info.Kind, param)
-		if err != nil {
-			return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-				"could not convert path param to %s: %s", reflect.Int, err.Error(),
-			))
-		}
+		deepObjects = append(deepObjects, params[key])
+		delete(params, key)
+	}
+	return deepObjects
+}
 
-		inputStruct.Elem().Field(info.Idx).Set(v)
+// buildCatchAllMap builds either a map[string]string or a map[string][]string
+// with all the params whose names start with the prefix informed on the tag.
+//
+// If normalizeKey is not nil the prefix is compared ignoring case,
+// and the keys are normalized before being inserted on the map.
+func buildCatchAllMap(
+	info tagInfo,
+	visit func(visitor func(key string, value string)),
+	normalizeKey func(string) string,
+) reflect.Value {
+	prefix := strings.TrimSuffix(info.Key, "*")
+	if normalizeKey != nil {
+		prefix = strings.ToLower(prefix)
 	}
-	for key, info := range funcInfo.queryParams {
-		param := request.GetQueryParam(key)
-		if param == "" {
-			param = info.Default
+
+	isMultiValue := info.Type.Elem().Kind() == reflect.Slice
+	m := reflect.Value{}
+	visit(func(key string, value string) {
+		k := key
+		if normalizeKey != nil {
+			k = strings.ToLower(key)
+		}
+		if !strings.HasPrefix(k, prefix) {
+			return
 		}
-		if param == "" {
-			if info.Required {
-				return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-					"required query param '%s' is empty", key,
-				))
-			}
 
-			continue
+		if normalizeKey != nil {
+			key = normalizeKey(key)
 		}
 
-		v, err := decodeType(info.Kind, param)
-		if err != nil {
-			return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-				"could not convert path param to %s: %s", reflect.Int, err.Error(),
-			))
+		if !m.IsValid() {
+			m = reflect.MakeMap(info.Type)
 		}
 
-		inputStruct.Elem().Field(info.Idx).Set(v)
+		keyV := reflect.ValueOf(key).Convert(info.Type.Key())
+		if !isMultiValue {
+			// Just like GetHeaderParam and GetQueryParam
+			// only the first value is kept:
+			if m.MapIndex(keyV).IsValid() {
+				return
+			}
+			m.SetMapIndex(keyV, reflect.ValueOf(value).Convert(info.Type.Elem()))
+			return
+		}
+
+		values := m.MapIndex(keyV)
+		if !values.IsValid() {
+			values = reflect.MakeSlice(info.Type.Elem(), 0, 1)
+		}
+		values = reflect.Append(values, reflect.ValueOf(value).Convert(info.Type.Elem().Elem()))
+		m.SetMapIndex(keyV, values)
+	})
+
+	if !m.IsValid() {
+		return reflect.Zero(info.Type)
 	}
+	return m
+}
 
-	for key, info := range funcInfo.contextValues {
-		param := request.GetContextValue(key)
-		if info.Required && param == nil {
-			return reflect.Value{}, request.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-				"required user value '%s' is empty", key,
-			))
+// handleError passes the decoding error to the configured
+// ErrorHandler and returns the error it produces.
+func (d DecodedHandlerFunction) handleError(request RequestAdapter, err *DecodingError) error {
+	errorHandler := d.errorHandler
+	if errorHandler == nil {
+		errorHandler = DefaultErrorHandler
+	}
+	return errorHandler(request, err)
+}
+
+// decodeContextValue reads the value from the request context and stores it on the target
+func decodeContextValue(request RequestAdapter, handleError ErrorHandler, key string, info tagInfo, target reflect.Value) error {
+	param := request.GetContextValue(info.contextKey)
+	value, found, ok := assignableContextValue(param, info.Type)
+	if !found {
+		if info.Required {
+			return handleError(request, newMissingValueError("context", key, info.Name))
 		}
+		return nil
+	}
 
-		paramV := reflect.ValueOf(param)
-		canConvert := paramV.Type().ConvertibleTo(info.Type)
-		if !canConvert {
-			return reflect.Value{}, request.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf(
-				"could not convert userValue %s to type %T", param, info.Type,
-			))
+	if !ok {
+		return handleError(request, &DecodingError{
+			StatusCode: http.StatusInternalServerError,
+			Source:     "context",
+			Key:        key,
+			Field:      info.Name,
+			Reason:     ReasonInvalidContextValue,
+			Message: fmt.Sprintf(
+				"context value '%s' has type %T which can't be assigned to field %s of type %v",
+				key, param, info.Name, info.Type,
+			),
+		})
+	}
+
+	target.Set(value)
+	return nil
+}
+
+func newConversionError(source string, key string, info tagInfo, err error) *DecodingError {
+	return &DecodingError{
+		StatusCode: http.StatusBadRequest,
+		Source:     source,
+		Key:        key,
+		Field:      info.Name,
+		Reason:     ReasonInvalidValue,
+		Message:    fmt.Sprintf("could not convert %s param '%s' to %s: %s", source, key, info.Kind, err.Error()),
+		Err:        err,
+	}
+}
+
+func newBodyError(field string, err error) *DecodingError {
+	return &DecodingError{
+		StatusCode: http.StatusBadRequest,
+		Source:     "body",
+		Field:      field,
+		Reason:     ReasonInvalidValue,
+		Message:    fmt.Sprintf("could not parse body as JSON: %s", err.Error()),
+		Err:        err,
+	}
+}
+
+func newMissingValueError(source string, key string, field string) *DecodingError {
+	var message string
+	switch source {
+	case "path":
+		message = fmt.Sprintf("path param '%s' is empty", key)
+	case "cookie":
+		message = fmt.Sprintf("required cookie '%s' is empty", key)
+	case "context":
+		message = fmt.Sprintf("required context value '%s' is empty", key)
+	default:
+		message = fmt.Sprintf("required %s param '%s' is empty", source, key)
+	}
+
+	return &DecodingError{
+		StatusCode: http.StatusBadRequest,
+		Source:     source,
+		Key:        key,
+		Field:      field,
+		Reason:     ReasonMissingValue,
+		Message:    message,
+	}
+}
+
+// assignableContextValue prepares a value read from the request context for
+// being stored on a field of type t, it returns found as false for nil values,
+// including typed nils such as a nil *Logger, and ok as false if the value is
+// not compatible with the type of the field.
+//
+// Besides the values assignable to t, e.g. a concrete logger for a field
+// of an interface type, pointers are dereferenced for fields of the pointed
+// type and values are copied into a new pointer for fields of pointer types.
+func assignableContextValue(param any, t reflect.Type) (_ reflect.Value, found bool, ok bool) {
+	if param == nil {
+		return reflect.Value{}, false, false
+	}
+
+	v := reflect.ValueOf(param)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		if v.IsNil() {
+			return reflect.Value{}, false, false
 		}
+	}
 
-		inputStruct.Elem().Field(info.Idx).Set(paramV.Convert(info.Type))
+	if v.Type().AssignableTo(t) {
+		return v, true, true
 	}
 
-	return inputStruct, nil
+	if v.Kind() == reflect.Ptr && v.Type().Elem().AssignableTo(t) {
+		return v.Elem(), true, true
+	}
+
+	if t.Kind() == reflect.Ptr && v.Type().AssignableTo(t.Elem()) {
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(v)
+		return ptr, true, true
+	}
+
+	// Named types with the same underlying type, e.g. `type UserID string`,
+	// are converted, but not different kinds such as an int into a string:
+	if v.Kind() == t.Kind() && v.Type().ConvertibleTo(t) {
+		return v.Convert(t), true, true
+	}
+
+	// Numbers are converted between the int, uint and float kinds, e.g. an
+	// int stored by a middleware into an int64 field, as long as the value
+	// fits on the field without being truncated:
+	if isNumberKind(v.Kind()) && isNumberKind(t.Kind()) {
+		converted := v.Convert(t)
+		if converted.Convert(v.Type()).Interface() != v.Interface() || isNegative(converted) != isNegative(v) {
+			return reflect.Value{}, true, false
+		}
+		return converted, true, true
+	}
+
+	return reflect.Value{}, true, false
 }
 
-func decodeType(kind reflect.Kind, v string) (reflect.Value, error) {
+func isNegative(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() < 0
+	}
+	return false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
 	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeType parses the string into a value of type t,
+// the supported kinds are listed on isDecodableKind.
+func decodeType(t reflect.Type, v string) (reflect.Value, error) {
+	var value reflect.Value
+	var err error
+	switch t.Kind() {
 	case reflect.Int:
-		i, err := strconv.Atoi(v)
-		return reflect.ValueOf(i), err
+		var i int
+		i, err = strconv.Atoi(v)
+		value = reflect.ValueOf(i)
 	case reflect.Int8:
-		i, err := strconv.ParseInt(v, 10, 8)
-		return reflect.ValueOf(int8(i)), err
+		var i int64
+		i, err = strconv.ParseInt(v, 10, 8)
+		value = reflect.ValueOf(int8(i))
 	case reflect.Int16:
-		i, err := strconv.ParseInt(v, 10, 16)
-		return reflect.ValueOf(int16(i)), err
+		var i int64
+		i, err = strconv.ParseInt(v, 10, 16)
+		value = reflect.ValueOf(int16(i))
 	case reflect.Int32:
-		i, err := strconv.ParseInt(v, 10, 32)
-		return reflect.ValueOf(int32(i)), err
+		var i int64
+		i, err = strconv.ParseInt(v, 10, 32)
+		value = reflect.ValueOf(int32(i))
 	case reflect.Int64:
-		i, err := strconv.ParseInt(v, 10, 64)
-		return reflect.ValueOf(int64(i)), err
+		var i int64
+		i, err = strconv.ParseInt(v, 10, 64)
+		value = reflect.ValueOf(i)
 
 	case reflect.Uint:
-		i, err := strconv.Atoi(v)
-		return reflect.ValueOf(uint(i)), err
+		var i uint64
+		i, err = strconv.ParseUint(v, 10, 0)
+		value = reflect.ValueOf(uint(i))
 	case reflect.Uint8:
-		i, err := strconv.ParseUint(v, 10, 8)
-		return reflect.ValueOf(uint8(i)), err
+		var i uint64
+		i, err = strconv.ParseUint(v, 10, 8)
+		value = reflect.ValueOf(uint8(i))
 	case reflect.Uint16:
-		i, err := strconv.ParseUint(v, 10, 16)
-		return reflect.ValueOf(uint16(i)), err
+		var i uint64
+		i, err = strconv.ParseUint(v, 10, 16)
+		value = reflect.ValueOf(uint16(i))
 	case reflect.Uint32:
-		i, err := strconv.ParseUint(v, 10, 32)
-		return reflect.ValueOf(uint32(i)), err
+		var i uint64
+		i, err = strconv.ParseUint(v, 10, 32)
+		value = reflect.ValueOf(uint32(i))
 	case reflect.Uint64:
-		i, err := strconv.ParseUint(v, 10, 64)
-		return reflect.ValueOf(uint64(i)), err
+		var i uint64
+		i, err = strconv.ParseUint(v, 10, 64)
+		value = reflect.ValueOf(i)
+
+	default:
+		value = reflect.ValueOf(v)
 	}
 
-	return reflect.ValueOf(v), nil
+	// Convert is necessary for named types, e.g. `type UserID int`:
+	return value.Convert(t), err
+}
+
+func isDecodableKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
 type tagInfo struct {
-	Idx      int
+	// Index is the path to the field as expected by `FieldByIndex`
+	// it has more than one element for fields of nested structs
+	Index    []int
+	Name     string
+	Key      string
 	Required bool
 	Kind     reflect.Kind
 	Type     reflect.Type
 	Default  string // TODO: use a reflect.Value instead for saving on the conversion time
+
+	// hasDefault distinguishes the params tagged with `default:""`,
+	// whose Default is also empty, from the ones without a default
+	hasDefault bool
+
+	// CatchAll is true for map fields receiving all the params whose
+	// names start with the Key without the trailing "*", e.g. `header:"X-Meta-*"`
+	CatchAll bool
+
+	// deepObject is not nil for query params using the
+	// bracket notation, e.g. `query:"filter,deepObject"`
+	deepObject *deepObjectInfo
+
+	// contextKey is the key used for reading `context` values, it is either
+	// the Key itself or the typed key informed with WithContextKey
+	contextKey any
 }
 
-func getBodyInfo(t reflect.Type) (contentType string, info *tagInfo) {
+func getBodyInfo(t reflect.Type) (contentType string, info *tagInfo, _ error) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		name := field.Name
@@ -249,122 +612,374 @@ func getBodyInfo(t reflect.Type) (contentType string, info *tagInfo) {
 			case "application/json":
 			case "application/octet-stream":
 			default:
-				panic(fmt.Sprintf(
+				return "", nil, fmt.Errorf(
 					"mimetype '%s' is not supported yet for field %s",
 					contentType,
 					field.Name,
-				))
+				)
 			}
 
 			return contentType, &tagInfo{
-				Idx:      i,
+				Index:    []int{i},
+				Name:     field.Name,
 				Required: true,
 				Kind:     field.Type.Kind(),
 				Type:     field.Type,
-			}
+			}, nil
 		}
 	}
 
-	return "", nil
+	return "", nil, nil
+}
+
+// tagSource describes the rules of each of the tags
+// used for reading values from the request
+type tagSource struct {
+	name              string
+	requiredByDefault bool
+	allowedOptions    []string
+	allowsDefault     bool
+
+	// onlyDecodableKinds is true for sources where the values are
+	// received as strings and must be parsed with decodeType
+	onlyDecodableKinds bool
+	onlyStrings        bool
+
+	// allowedKeys is nil if any key is allowed
+	allowedKeys []string
+
+	// allowsCatchAll is true for sources that accept keys like `*`
+	// or `X-Meta-*` for filling a map with all the matching params
+	allowsCatchAll bool
+
+	// normalizeKey is used for detecting duplicated keys,
+	// e.g. header names are case insensitive
+	normalizeKey func(key string) string
+}
+
+var tagSources = []tagSource{
+	{
+		name:               "path",
+		requiredByDefault:  true,
+		onlyDecodableKinds: true,
+	},
+	{
+		name:               "header",
+		requiredByDefault:  true,
+		allowedOptions:     []string{"optional", "required"},
+		allowsDefault:      true,
+		onlyDecodableKinds: true,
+		allowsCatchAll:     true,
+		normalizeKey:       textproto.CanonicalMIMEHeaderKey,
+	},
+	{
+		name:               "query",
+		requiredByDefault:  false,
+		allowedOptions:     []string{"optional", "required", "deepObject"},
+		allowsDefault:      true,
+		onlyDecodableKinds: true,
+		allowsCatchAll:     true,
+	},
+	{
+		name:               "cookie",
+		requiredByDefault:  true,
+		allowedOptions:     []string{"optional", "required"},
+		allowsDefault:      true,
+		onlyDecodableKinds: true,
+	},
+	{
+		name:              "context",
+		requiredByDefault: true,
+		allowedOptions:    []string{"optional", "required"},
+	},
+	{
+		name:        "request",
+		onlyStrings: true,
+		allowedKeys: requestInfoKeys,
+	},
 }
 
 // This function collects only the names
 // that will be used from the type
 // this should save several calls to `Field(i).Tag.Get("foo")`
 // which might improve the performance by a lot.
-func getTagNames(t reflect.Type) (
-	pathParams map[string]tagInfo,
-	headerParams map[string]tagInfo,
-	queryParams map[string]tagInfo,
-	contextValues map[string]tagInfo,
-) {
-	pathParams = map[string]tagInfo{}
-	headerParams = map[string]tagInfo{}
-	queryParams = map[string]tagInfo{}
-	contextValues = map[string]tagInfo{}
+//
+// It also checks the tags for common mistakes, returning
+// a description of each problem found so they can be
+// reported during startup instead of at request time.
+func getTagNames(t reflect.Type) (paramsBySource map[string]map[string]tagInfo, problems []string) {
+	paramsBySource = map[string]map[string]tagInfo{}
+	for _, source := range tagSources {
+		paramsBySource[source.name] = map[string]tagInfo{}
+	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		opts := strings.Split(field.Tag.Get("path"), ",")
+	c := tagCollector{
+		paramsBySource: paramsBySource,
+		usedKeys:       map[string]map[string]string{},
+	}
+	c.collectFields(t, nil, "")
 
-		key := opts[0]
-		if key == "" {
-			continue
-		}
+	return paramsBySource, c.problems
+}
 
-		pathParams[key] = tagInfo{
-			Idx:      i,
-			Required: true,
-			Kind:     field.Type.Kind(),
-			Type:     field.Type,
-		}
-	}
+// tagCollector walks through the fields of the args struct,
+// including the fields of nested structs, collecting the tag
+// information of each of them.
+type tagCollector struct {
+	paramsBySource map[string]map[string]tagInfo
+
+	// usedKeys maps the normalized keys of each source
+	// to the name of the field that first used it:
+	usedKeys map[string]map[string]string
 
+	problems []string
+}
+
+func (c *tagCollector) collectFields(t reflect.Type, parentIndex []int, namePrefix string) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		opts := strings.Split(field.Tag.Get("header"), ",")
+		name := namePrefix + field.Name
+
+		// The index is copied so the slices of
+		// different fields don't share memory:
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		var sourcesUsed []string
+		for _, source := range tagSources {
+			tag, ok := field.Tag.Lookup(source.name)
+			if !ok {
+				continue
+			}
+			sourcesUsed = append(sourcesUsed, source.name)
 
-		key := opts[0]
-		if key == "" {
-			continue
-		}
+			info, fieldProblems := parseTag(source, field, name, tag)
+			c.problems = append(c.problems, fieldProblems...)
+			if info.Key == "" {
+				continue
+			}
+			info.Index = index
+
+			normalizedKey := info.Key
+			if source.normalizeKey != nil {
+				normalizedKey = source.normalizeKey(info.Key)
+			}
+			if c.usedKeys[source.name] == nil {
+				c.usedKeys[source.name] = map[string]string{}
+			}
+			if otherField, found := c.usedKeys[source.name][normalizedKey]; found {
+				c.problems = append(c.problems, fmt.Sprintf(
+					"fields %s and %s are both reading the %s param '%s'",
+					otherField, name, source.name, info.Key,
+				))
+				continue
+			}
+			c.usedKeys[source.name][normalizedKey] = name
 
-		required := true
-		if len(opts) > 1 && opts[1] == "optional" {
-			required = false
+			c.paramsBySource[source.name][info.Key] = info
 		}
 
-		headerParams[key] = tagInfo{
-			Idx:      i,
-			Required: required,
-			Kind:     field.Type.Kind(),
-			Type:     field.Type,
-			Default:  field.Tag.Get("default"),
+		if len(sourcesUsed) > 1 {
+			c.problems = append(c.problems, fmt.Sprintf(
+				"field %s must have a single source tag but it has: %s",
+				name, strings.Join(sourcesUsed, ", "),
+			))
 		}
-	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		opts := strings.Split(field.Tag.Get("query"), ",")
-		key := opts[0]
-		if key == "" {
+		inline, ok := field.Tag.Lookup("kapi")
+		if ok && inline != "inline" {
+			c.problems = append(c.problems, fmt.Sprintf(
+				"unknown value '%s' on the kapi tag of field %s, the only value supported is 'inline'", inline, name,
+			))
 			continue
 		}
 
-		required := false
-		if len(opts) > 1 && opts[1] == "required" {
-			required = true
+		// Embedded structs and the fields tagged with `kapi:"inline"` are treated
+		// as groups of params, e.g. for reusing the same pagination params:
+		if len(sourcesUsed) > 0 || !(field.Anonymous || ok) {
+			continue
 		}
 
-		queryParams[key] = tagInfo{
-			Idx:      i,
-			Required: required,
-			Kind:     field.Type.Kind(),
-			Type:     field.Type,
-			Default:  field.Tag.Get("default"),
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if ok && !field.IsExported() {
+				c.problems = append(c.problems, fmt.Sprintf(
+					"field %s is tagged with `kapi:\"inline\"` but it is not exported", name,
+				))
+				continue
+			}
+			c.collectFields(field.Type, index, name+".")
+		case ok:
+			c.problems = append(c.problems, fmt.Sprintf(
+				"only struct fields can be tagged with `kapi:\"inline\"` but %s is of type %v", name, field.Type,
+			))
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			c.problems = append(c.problems, fmt.Sprintf(
+				"embedded pointers are not supported, use %v instead of %v on the args struct", field.Type.Elem(), field.Type,
+			))
 		}
 	}
+}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		opts := strings.Split(field.Tag.Get("context"), ",")
-		key := opts[0]
-		if key == "" {
-			continue
+func parseTag(source tagSource, field reflect.StructField, name string, tag string) (info tagInfo, problems []string) {
+	opts := strings.Split(tag, ",")
+	info = tagInfo{
+		Name:     name,
+		Key:      opts[0],
+		Required: source.requiredByDefault,
+		Kind:     field.Type.Kind(),
+		Type:     field.Type,
+		Default:  field.Tag.Get("default"),
+	}
+	_, info.hasDefault = field.Tag.Lookup("default")
+
+	if info.Key == "" {
+		problems = append(problems, fmt.Sprintf(
+			"the %s tag of field %s must not be empty", source.name, name,
+		))
+	}
+
+	if !field.IsExported() {
+		problems = append(problems, fmt.Sprintf(
+			"field %s is tagged with `%s` but it is not exported", name, source.name,
+		))
+	}
+
+	explicitlyRequired := false
+	isDeepObject := false
+	for _, opt := range opts[1:] {
+		switch {
+		case source.name == "path" && opt == "optional":
+			problems = append(problems, fmt.Sprintf(
+				"path params are always required, so field %s can't be marked as optional", name,
+			))
+		case !contains(source.allowedOptions, opt):
+			problems = append(problems, fmt.Sprintf(
+				"unknown option '%s' on the %s tag of field %s", opt, source.name, name,
+			))
+		case opt == "optional":
+			info.Required = false
+		case opt == "required":
+			info.Required = true
+			explicitlyRequired = true
+		case opt == "deepObject":
+			isDeepObject = true
 		}
+	}
 
-		required := true
-		if len(opts) > 1 && opts[1] == "optional" {
-			required = false
+	if isDeepObject {
+		deepObject, deepObjectProblems := compileDeepObject(field.Type, name)
+		info.deepObject = deepObject
+		problems = append(problems, deepObjectProblems...)
+		if _, hasDefault := field.Tag.Lookup("default"); hasDefault {
+			problems = append(problems, fmt.Sprintf(
+				"deepObject fields can't have a default value, but field %s has one", name,
+			))
 		}
+		return info, problems
+	}
+
+	if source.allowsCatchAll && strings.HasSuffix(info.Key, "*") {
+		return parseCatchAllTag(source, field, name, info, explicitlyRequired, problems)
+	}
+
+	if source.allowedKeys != nil && info.Key != "" && !contains(source.allowedKeys, info.Key) {
+		problems = append(problems, fmt.Sprintf(
+			"unknown key '%s' on the %s tag of field %s, the supported keys are: %s",
+			info.Key, source.name, name, strings.Join(source.allowedKeys, ", "),
+		))
+	}
 
-		contextValues[key] = tagInfo{
-			Idx:      i,
-			Required: required,
-			Kind:     field.Type.Kind(),
-			Type:     field.Type,
+	if source.onlyStrings && field.Type.Kind() != reflect.String {
+		problems = append(problems, fmt.Sprintf(
+			"field %s has type %v but %s fields must be strings", name, field.Type, source.name,
+		))
+	}
+
+	if source.onlyDecodableKinds && !isDecodableKind(field.Type.Kind()) {
+		problems = append(problems, fmt.Sprintf(
+			"field %s has type %v which is not supported for %s params", name, field.Type, source.name,
+		))
+	}
+
+	if _, hasDefault := field.Tag.Lookup("default"); hasDefault {
+		if source.allowsDefault {
+			problems = append(problems, checkDefaultValue(field.Type, name, info.Default, explicitlyRequired)...)
+		} else {
+			problems = append(problems, fmt.Sprintf(
+				"%s params can't have a default value, but field %s has one", source.name, name,
+			))
 		}
 	}
 
-	return
+	return info, problems
+}
+
+// checkDefaultValue checks if the default value of a field
+// would be used and if it can be decoded into the field type.
+func checkDefaultValue(t reflect.Type, name string, defaultValue string, explicitlyRequired bool) []string {
+	if explicitlyRequired {
+		return []string{fmt.Sprintf(
+			"field %s is required so its default value would never be used", name,
+		)}
+	}
+
+	if !isDecodableKind(t.Kind()) {
+		return nil
+	}
+
+	if _, err := decodeType(t, defaultValue); err != nil {
+		return []string{fmt.Sprintf(
+			"invalid default value for field %s: %s", name, err.Error(),
+		)}
+	}
+
+	return nil
+}
+
+// parseCatchAllTag parses tags like `header:"*"` or `header:"X-Meta-*"`
+// used on map fields for receiving all the params matching the prefix.
+func parseCatchAllTag(
+	source tagSource,
+	field reflect.StructField,
+	name string,
+	info tagInfo,
+	explicitlyRequired bool,
+	problems []string,
+) (tagInfo, []string) {
+	info.CatchAll = true
+	info.Required = false
+
+	if explicitlyRequired {
+		problems = append(problems, fmt.Sprintf(
+			"catch-all fields can't be required, but field %s is", name,
+		))
+	}
+
+	if _, hasDefault := field.Tag.Lookup("default"); hasDefault {
+		problems = append(problems, fmt.Sprintf(
+			"catch-all fields can't have a default value, but field %s has one", name,
+		))
+	}
+
+	t := field.Type
+	isValidMap := t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+		(t.Elem().Kind() == reflect.String || (t.Elem().Kind() == reflect.Slice && t.Elem().Elem().Kind() == reflect.String))
+	if !isValidMap {
+		problems = append(problems, fmt.Sprintf(
+			"catch-all field %s must be of type map[string]string or map[string][]string, but it is %v", name, t,
+		))
+	}
+
+	return info, problems
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
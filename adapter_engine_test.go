@@ -0,0 +1,553 @@
+package kapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+// handlerType builds the type of a handler receiving
+// a context.Context and an args struct of the informed type
+func handlerType(args interface{}) reflect.Type {
+	return reflect.FuncOf(
+		[]reflect.Type{contextType, reflect.TypeOf(args)},
+		[]reflect.Type{errType},
+		false,
+	)
+}
+
+func TestTryDecodeHandlerFunction(t *testing.T) {
+	t.Run("should accept valid args structs", func(t *testing.T) {
+		_, err := TryDecodeHandlerFunction(handlerType(struct {
+			ID     int    `path:"id"`
+			Brand  string `header:"brand,optional"`
+			Limit  int    `query:"limit" default:"10"`
+			Token  string `cookie:"token"`
+			User   string `context:"user,optional"`
+			Method string `request:"method"`
+			Body   []byte
+		}{}), []reflect.Type{contextType})
+		tt.AssertNoErr(t, err)
+	})
+
+	tests := []struct {
+		desc           string
+		args           interface{}
+		expectedErrors []string
+	}{
+		{
+			desc: "should report unexported tagged fields",
+			args: struct {
+				id int `path:"id"`
+			}{},
+			expectedErrors: []string{"field id is tagged with `path` but it is not exported"},
+		},
+		{
+			desc: "should report kinds not supported by the source",
+			args: struct {
+				Filter struct{} `query:"filter"`
+			}{},
+			expectedErrors: []string{"field Filter has type struct {} which is not supported for query params"},
+		},
+		{
+			desc: "should report request fields that are not strings",
+			args: struct {
+				Method int `request:"method"`
+			}{},
+			expectedErrors: []string{"field Method has type int but request fields must be strings"},
+		},
+		{
+			desc: "should report duplicated keys",
+			args: struct {
+				A string `query:"name"`
+				B string `query:"name"`
+			}{},
+			expectedErrors: []string{"fields A and B are both reading the query param 'name'"},
+		},
+		{
+			desc: "should report duplicated headers ignoring the case",
+			args: struct {
+				A string `header:"x-brand"`
+				B string `header:"X-Brand"`
+			}{},
+			expectedErrors: []string{"fields A and B are both reading the header param 'X-Brand'"},
+		},
+		{
+			desc: "should report defaults on required params",
+			args: struct {
+				Limit int `query:"limit,required" default:"10"`
+			}{},
+			expectedErrors: []string{"field Limit is required so its default value would never be used"},
+		},
+		{
+			desc: "should report defaults that can't be parsed",
+			args: struct {
+				Limit int `query:"limit" default:"ten"`
+			}{},
+			expectedErrors: []string{"invalid default value for field Limit"},
+		},
+		{
+			desc: "should report defaults on sources that don't support them",
+			args: struct {
+				ID int `path:"id" default:"1"`
+			}{},
+			expectedErrors: []string{"path params can't have a default value, but field ID has one"},
+		},
+		{
+			desc: "should report optional path params",
+			args: struct {
+				ID int `path:"id,optional"`
+			}{},
+			expectedErrors: []string{"path params are always required, so field ID can't be marked as optional"},
+		},
+		{
+			desc: "should report unknown options",
+			args: struct {
+				Brand string `header:"brand,optinal"`
+			}{},
+			expectedErrors: []string{"unknown option 'optinal' on the header tag of field Brand"},
+		},
+		{
+			desc: "should report unknown request keys",
+			args: struct {
+				IP string `request:"ip"`
+			}{},
+			expectedErrors: []string{"unknown key 'ip' on the request tag of field IP"},
+		},
+		{
+			desc: "should report empty tags",
+			args: struct {
+				Brand string `header:""`
+			}{},
+			expectedErrors: []string{"the header tag of field Brand must not be empty"},
+		},
+		{
+			desc: "should report fields with more than one source",
+			args: struct {
+				Brand string `header:"brand" query:"brand"`
+			}{},
+			expectedErrors: []string{"field Brand must have a single source tag but it has: header, query"},
+		},
+		{
+			desc: "should report unsupported body content types",
+			args: struct {
+				Body string `content-type:"text/xml"`
+			}{},
+			expectedErrors: []string{"mimetype 'text/xml' is not supported yet for field Body"},
+		},
+		{
+			desc: "should report every problem at once",
+			args: struct {
+				id    int    `path:"id"`
+				Brand string `header:"brand,optinal"`
+				Limit int    `query:"limit,required" default:"10"`
+			}{},
+			expectedErrors: []string{
+				"field id is tagged with `path` but it is not exported",
+				"unknown option 'optinal' on the header tag of field Brand",
+				"field Limit is required so its default value would never be used",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := TryDecodeHandlerFunction(handlerType(test.args), []reflect.Type{contextType})
+			tt.AssertErrContains(t, err, test.expectedErrors...)
+
+			var handlerErr *HandlerError
+			tt.AssertEqual(t, errors.As(err, &handlerErr), true)
+			tt.AssertEqual(t, len(handlerErr.Problems), len(test.expectedErrors))
+		})
+	}
+
+	t.Run("should report invalid handler shapes", func(t *testing.T) {
+		_, err := TryDecodeHandlerFunction(reflect.TypeOf(func(ctx string, args struct{}) (int, string) {
+			return 0, ""
+		}), []reflect.Type{reflect.TypeOf(0)})
+		tt.AssertErrContains(t, err,
+			"argument 1 must be of type int or context.Context!",
+			"last return value must be of type error",
+		)
+
+		_, err = TryDecodeHandlerFunction(reflect.TypeOf(42), []reflect.Type{contextType})
+		tt.AssertErrContains(t, err, "adapt's argument must be a function!")
+	})
+}
+
+type pagination struct {
+	Page int `query:"page" default:"1"`
+	Size int `query:"size"`
+}
+
+type Sorting struct {
+	OrderBy string `query:"order_by"`
+}
+
+func TestNestedArgsStructs(t *testing.T) {
+	type args struct {
+		Brand string `header:"brand"`
+		pagination
+		Filters struct {
+			Sorting
+			Status string `query:"status"`
+		} `kapi:"inline"`
+	}
+
+	t.Run("should compute the index path of every nested field", func(t *testing.T) {
+		params, problems := getTagNames(reflect.TypeOf(args{}))
+		tt.AssertEqual(t, len(problems), 0)
+
+		tt.AssertEqual(t, params["header"]["brand"].Index, []int{0})
+		tt.AssertEqual(t, params["query"]["page"].Index, []int{1, 0})
+		tt.AssertEqual(t, params["query"]["page"].Name, "pagination.Page")
+		tt.AssertEqual(t, params["query"]["size"].Index, []int{1, 1})
+		tt.AssertEqual(t, params["query"]["order_by"].Index, []int{2, 0, 0})
+		tt.AssertEqual(t, params["query"]["order_by"].Name, "Filters.Sorting.OrderBy")
+		tt.AssertEqual(t, params["query"]["status"].Index, []int{2, 1})
+	})
+
+	t.Run("should not share the memory of the index paths", func(t *testing.T) {
+		params, _ := getTagNames(reflect.TypeOf(args{}))
+
+		page := params["query"]["page"].Index
+		page[0] = 42
+		tt.AssertEqual(t, params["query"]["size"].Index, []int{1, 1})
+	})
+
+	t.Run("should decode the nested fields", func(t *testing.T) {
+		decoded, err := decodeArgs(&fakeRequest{
+			headers: http.Header{"Brand": {"fake-brand"}},
+			query:   url.Values{"size": {"10"}, "order_by": {"name"}, "status": {"active"}},
+		}, args{})
+		tt.AssertNoErr(t, err)
+
+		expected := args{Brand: "fake-brand"}
+		expected.Page = 1
+		expected.Size = 10
+		expected.Filters.OrderBy = "name"
+		expected.Filters.Status = "active"
+		tt.AssertEqual(t, decoded, expected)
+	})
+
+	t.Run("should report duplicated keys across nested structs", func(t *testing.T) {
+		_, err := TryDecodeHandlerFunction(handlerType(struct {
+			Page int `query:"page"`
+			pagination
+		}{}), []reflect.Type{contextType})
+		tt.AssertErrContains(t, err, "fields Page and pagination.Page are both reading the query param 'page'")
+	})
+
+	tests := []struct {
+		desc          string
+		args          interface{}
+		expectedError string
+	}{
+		{
+			desc: "should report embedded pointers",
+			args: struct {
+				*Sorting
+			}{},
+			expectedError: "embedded pointers are not supported, use kapi.Sorting instead of *kapi.Sorting on the args struct",
+		},
+		{
+			desc: "should report inline fields that are not structs",
+			args: struct {
+				Page int `kapi:"inline"`
+			}{},
+			expectedError: "only struct fields can be tagged with `kapi:\"inline\"` but Page is of type int",
+		},
+		{
+			desc: "should report unexported inline fields",
+			args: struct {
+				sorting Sorting `kapi:"inline"`
+			}{},
+			expectedError: "field sorting is tagged with `kapi:\"inline\"` but it is not exported",
+		},
+		{
+			desc: "should report unknown values on the kapi tag",
+			args: struct {
+				Sorting Sorting `kapi:"inlined"`
+			}{},
+			expectedError: "unknown value 'inlined' on the kapi tag of field Sorting, the only value supported is 'inline'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := TryDecodeHandlerFunction(handlerType(test.args), []reflect.Type{contextType})
+			tt.AssertErrContains(t, err, test.expectedError)
+		})
+	}
+}
+
+func TestCatchAllParams(t *testing.T) {
+	request := &fakeRequest{
+		headers: http.Header{
+			"X-Meta-Region": {"us"},
+			"x-meta-zone":   {"a", "b"},
+			"Authorization": {"Bearer fake"},
+		},
+		query: url.Values{
+			"tag_a":  {"1", "2"},
+			"tag_b":  {"3"},
+			"TAG_c":  {"4"},
+			"limit":  {"10"},
+			"filter": {"x"},
+		},
+	}
+
+	t.Run("should filter the headers by prefix ignoring the case", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Meta map[string]string `header:"x-meta-*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded, struct {
+			Meta map[string]string `header:"x-meta-*"`
+		}{
+			// The names are normalized and only the first value is kept:
+			Meta: map[string]string{"X-Meta-Region": "us", "X-Meta-Zone": "a"},
+		})
+	})
+
+	t.Run("should keep every value on multi value maps", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Meta http.Header `header:"X-Meta-*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded, struct {
+			Meta http.Header `header:"X-Meta-*"`
+		}{
+			Meta: http.Header{"X-Meta-Region": {"us"}, "X-Meta-Zone": {"a", "b"}},
+		})
+	})
+
+	t.Run("should filter the query params by prefix respecting the case", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Tags url.Values `query:"tag_*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded, struct {
+			Tags url.Values `query:"tag_*"`
+		}{
+			Tags: url.Values{"tag_a": {"1", "2"}, "tag_b": {"3"}},
+		})
+	})
+
+	t.Run("should receive every param without a prefix", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Query map[string]string `query:"*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded, struct {
+			Query map[string]string `query:"*"`
+		}{
+			Query: map[string]string{"TAG_c": "4", "filter": "x", "limit": "10", "tag_a": "1", "tag_b": "3"},
+		})
+	})
+
+	t.Run("should leave the map nil when no params match", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Other map[string]string `query:"other_*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded, struct {
+			Other map[string]string `query:"other_*"`
+		}{})
+	})
+
+	t.Run("should coexist with params read individually", func(t *testing.T) {
+		decoded, err := decodeArgs(request, struct {
+			Limit int               `query:"limit"`
+			All   map[string]string `query:"*"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded.(struct {
+			Limit int               `query:"limit"`
+			All   map[string]string `query:"*"`
+		}).Limit, 10)
+	})
+
+	tests := []struct {
+		desc          string
+		args          interface{}
+		expectedError string
+	}{
+		{
+			desc: "should report required catch-all fields",
+			args: struct {
+				Meta map[string]string `header:"X-Meta-*,required"`
+			}{},
+			expectedError: "catch-all fields can't be required, but field Meta is",
+		},
+		{
+			desc: "should report catch-all fields with defaults",
+			args: struct {
+				Meta map[string]string `query:"*" default:"x"`
+			}{},
+			expectedError: "catch-all fields can't have a default value, but field Meta has one",
+		},
+		{
+			desc: "should report catch-all fields of invalid types",
+			args: struct {
+				Meta map[string]int `query:"*"`
+			}{},
+			expectedError: "catch-all field Meta must be of type map[string]string or map[string][]string, but it is map[string]int",
+		},
+		{
+			desc: "should report catch-all keys on sources that don't support them",
+			args: struct {
+				Cookies map[string]string `cookie:"*"`
+			}{},
+			expectedError: "field Cookies has type map[string]string which is not supported for cookie params",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := TryDecodeHandlerFunction(handlerType(test.args), []reflect.Type{contextType})
+			tt.AssertErrContains(t, err, test.expectedError)
+		})
+	}
+}
+
+type fakeLogger struct{}
+
+func (l *fakeLogger) String() string {
+	return "fake-logger"
+}
+
+func TestContextValues(t *testing.T) {
+	type userID string
+
+	tests := []struct {
+		desc          string
+		value         interface{}
+		args          interface{}
+		expectedValue interface{}
+	}{
+		{
+			desc:  "should convert ints into other integer kinds",
+			value: 42,
+			args: struct {
+				N int64 `context:"value"`
+			}{},
+			expectedValue: struct {
+				N int64 `context:"value"`
+			}{N: 42},
+		},
+		{
+			desc:  "should convert between signed, unsigned and floats",
+			value: uint8(7),
+			args: struct {
+				N float64 `context:"value"`
+			}{},
+			expectedValue: struct {
+				N float64 `context:"value"`
+			}{N: 7},
+		},
+		{
+			desc:  "should convert named types of the same kind",
+			value: "fake-id",
+			args: struct {
+				ID userID `context:"value"`
+			}{},
+			expectedValue: struct {
+				ID userID `context:"value"`
+			}{ID: "fake-id"},
+		},
+		{
+			desc:  "should treat typed nils as missing values on optional fields",
+			value: (*fakeLogger)(nil),
+			args: struct {
+				Logger fmt.Stringer `context:"value,optional"`
+			}{},
+			expectedValue: struct {
+				Logger fmt.Stringer `context:"value,optional"`
+			}{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			request := &fakeRequest{}
+			request.SetContextValue("value", test.value)
+
+			decoded, err := decodeArgs(request, test.args)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, decoded, test.expectedValue)
+		})
+	}
+
+	errorTests := []struct {
+		desc          string
+		value         interface{}
+		args          interface{}
+		expectedError string
+	}{
+		{
+			desc:  "should fail when a required interface receives a typed nil",
+			value: (*fakeLogger)(nil),
+			args: struct {
+				Logger fmt.Stringer `context:"value"`
+			}{},
+			expectedError: "400: required context value 'value' is empty",
+		},
+		{
+			desc:  "should fail when a required pointer receives a nil pointer",
+			value: (*fakeLogger)(nil),
+			args: struct {
+				Logger *fakeLogger `context:"value"`
+			}{},
+			expectedError: "400: required context value 'value' is empty",
+		},
+		{
+			desc:  "should not truncate numbers that don't fit on the field",
+			value: 300,
+			args: struct {
+				N int8 `context:"value"`
+			}{},
+			expectedError: "500: context value 'value' has type int which can't be assigned to field N of type int8",
+		},
+		{
+			desc:  "should not convert negative numbers into unsigned fields",
+			value: -1,
+			args: struct {
+				N uint64 `context:"value"`
+			}{},
+			expectedError: "500: context value 'value' has type int which can't be assigned to field N of type uint64",
+		},
+		{
+			desc:  "should not truncate floats into integer fields",
+			value: 1.5,
+			args: struct {
+				N int `context:"value"`
+			}{},
+			expectedError: "500: context value 'value' has type float64 which can't be assigned to field N of type int",
+		},
+		{
+			desc:  "should not convert numbers into strings",
+			value: 42,
+			args: struct {
+				S string `context:"value"`
+			}{},
+			expectedError: "500: context value 'value' has type int which can't be assigned to field S of type string",
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.desc, func(t *testing.T) {
+			request := &fakeRequest{}
+			request.SetContextValue("value", test.value)
+
+			_, err := decodeArgs(request, test.args)
+			tt.AssertErrContains(t, err, test.expectedError)
+		})
+	}
+}
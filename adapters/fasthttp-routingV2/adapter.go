@@ -1,12 +1,14 @@
 package fasthttp_routing
 
 import (
+	"context"
+	"strings"
+
 	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/valyala/fasthttp"
 	"github.com/vingarcia/kapi"
 )
 
-type any = interface{}
-
 type Adapter struct {
 	ctx *routing.Context
 }
@@ -40,10 +42,116 @@ func (a Adapter) GetQueryParam(paramName string) string {
 	return string(a.ctx.Request.URI().QueryArgs().Peek(paramName))
 }
 
-func (a Adapter) GetContextValue(contextKey string) any {
-	return a.ctx.UserValue(contextKey)
+func (a Adapter) GetCookie(cookieName string) string {
+	return string(a.ctx.Request.Header.Cookie(cookieName))
+}
+
+func (a Adapter) VisitHeaders(visitor func(key string, value string)) {
+	a.ctx.Request.Header.VisitAll(func(key []byte, value []byte) {
+		visitor(string(key), string(value))
+	})
+}
+
+func (a Adapter) VisitQueryParams(visitor func(key string, value string)) {
+	a.ctx.QueryArgs().VisitAll(func(key []byte, value []byte) {
+		visitor(string(key), string(value))
+	})
+}
+
+func (a Adapter) GetMethod() string {
+	return string(a.ctx.Method())
+}
+
+func (a Adapter) GetPath() string {
+	return string(a.ctx.Path())
+}
+
+func (a Adapter) GetHost() string {
+	return string(a.ctx.Host())
+}
+
+func (a Adapter) GetScheme() string {
+	if a.ctx.IsTLS() {
+		return "https"
+	}
+	return "http"
+}
+
+func (a Adapter) GetRemoteIP() string {
+	return a.ctx.RemoteIP().String()
+}
+
+func (a Adapter) GetRawQuery() string {
+	return string(a.ctx.URI().QueryString())
+}
+
+func (a Adapter) GetRequestURI() string {
+	return string(a.ctx.RequestURI())
+}
+
+// typedValuesKey is the user value where the context holding
+// the values with keys that are not strings is stored, since
+// fasthttp only accepts string keys.
+const typedValuesKey = "kapi.typedContextValues"
+
+// GetContext returns a context whose values are the user values
+// of fasthttp plus the values stored with keys that are not strings.
+func (a Adapter) GetContext() context.Context {
+	if ctx, ok := a.ctx.UserValue(typedValuesKey).(context.Context); ok {
+		return ctx
+	}
+	return a.ctx.RequestCtx
+}
+
+func (a Adapter) GetContextValue(contextKey any) any {
+	if key, ok := contextKey.(string); ok {
+		return a.ctx.UserValue(key)
+	}
+	return a.GetContext().Value(contextKey)
+}
+
+func (a Adapter) SetContextValue(contextKey any, value any) {
+	if key, ok := contextKey.(string); ok {
+		a.ctx.SetUserValue(key, value)
+		return
+	}
+	a.ctx.SetUserValue(typedValuesKey, context.WithValue(a.GetContext(), contextKey, value))
+}
+
+func (a Adapter) SetStatus(statusCode int) {
+	a.ctx.SetStatusCode(statusCode)
+}
+
+func (a Adapter) SetHeader(key string, value string) {
+	a.ctx.Response.Header.Set(key, value)
+}
+
+func (a Adapter) SetCookie(cookie kapi.Cookie) {
+	c := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(c)
+
+	c.SetKey(cookie.Name)
+	c.SetValue(cookie.Value)
+	c.SetPath(cookie.Path)
+	c.SetDomain(cookie.Domain)
+	c.SetMaxAge(cookie.MaxAge)
+	c.SetExpire(cookie.Expires)
+	c.SetSecure(cookie.Secure)
+	c.SetHTTPOnly(cookie.HTTPOnly)
+
+	switch strings.ToLower(cookie.SameSite) {
+	case "lax":
+		c.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	case "strict":
+		c.SetSameSite(fasthttp.CookieSameSiteStrictMode)
+	case "none":
+		c.SetSameSite(fasthttp.CookieSameSiteNoneMode)
+	}
+
+	a.ctx.Response.Header.SetCookie(c)
 }
 
-func (a Adapter) SetContextValue(contextKey string, value any) {
-	a.ctx.SetUserValue(contextKey, value)
+func (a Adapter) WriteBody(body []byte) error {
+	a.ctx.SetBody(body)
+	return nil
 }
@@ -0,0 +1,85 @@
+package fasthttp_routing
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"testing"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"github.com/vingarcia/kapi/adaptertest"
+)
+
+func TestAdapter(t *testing.T) {
+	adaptertest.Run(t, func(req adaptertest.Request, handler adaptertest.Handler) adaptertest.Response {
+		router := routing.New()
+		route, url := buildRoute(req)
+		router.To(req.Method, route, func(ctx *routing.Context) error {
+			return handler(New(ctx))
+		})
+
+		// The request is served by an actual server, listening in memory,
+		// since `RequestCtx.Done()` depends on the server that created it:
+		ln := fasthttputil.NewInmemoryListener()
+		defer ln.Close()
+		go func() {
+			_ = fasthttp.Serve(ln, router.HandleRequest)
+		}()
+
+		client := fasthttp.Client{
+			Dial: func(addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		}
+
+		httpReq := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(httpReq)
+		httpReq.Header.SetMethod(req.Method)
+		httpReq.SetRequestURI("http://localhost" + url)
+		for key, value := range req.Headers {
+			httpReq.Header.Set(key, value)
+		}
+		httpReq.SetBody(req.Body)
+
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(resp)
+		err := client.Do(httpReq, resp)
+		if err != nil {
+			t.Fatalf("unexpected error serving the request: %s", err)
+		}
+
+		headers := http.Header{}
+		resp.Header.VisitAll(func(key []byte, value []byte) {
+			headers.Add(string(key), string(value))
+		})
+
+		return adaptertest.Response{
+			StatusCode: resp.StatusCode(),
+			Headers:    headers,
+			Body:       append([]byte(nil), resp.Body()...),
+		}
+	})
+}
+
+// buildRoute builds a route with one segment per path param of the request,
+// e.g. "/test/<id>/<org>", and the url matching it, e.g. "/test/42/fake-org?a=1"
+func buildRoute(req adaptertest.Request) (route string, url string) {
+	keys := make([]string, 0, len(req.PathParams))
+	for key := range req.PathParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	route, url = "/test", "/test"
+	for _, key := range keys {
+		route += "/<" + key + ">"
+		url += "/" + req.PathParams[key]
+	}
+
+	if req.RawQuery != "" {
+		url += "?" + req.RawQuery
+	}
+	return route, url
+}
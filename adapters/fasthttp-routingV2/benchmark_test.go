@@ -0,0 +1,45 @@
+package fasthttp_routing
+
+import (
+	"fmt"
+	"testing"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/vingarcia/kapi/adaptertest"
+)
+
+func BenchmarkRouting(b *testing.B) {
+	adaptertest.Benchmark(b, func(req adaptertest.Request, fn interface{}) func() error {
+		var handler routing.Handler
+		if h, ok := fn.(adaptertest.Handler); ok {
+			handler = func(ctx *routing.Context) error {
+				return h(New(ctx))
+			}
+		} else {
+			handler = Adapt(fn)
+		}
+
+		router := routing.New()
+		route, url := buildRoute(req)
+		router.To(req.Method, route, handler)
+
+		// The router is called directly with the same request
+		// so only the routing and the handler itself are measured:
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(req.Method)
+		ctx.Request.SetRequestURI(url)
+		for key, value := range req.Headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		ctx.Request.SetBody(req.Body)
+
+		return func() error {
+			router.HandleRequest(ctx)
+			if status := ctx.Response.StatusCode(); status != fasthttp.StatusOK {
+				return fmt.Errorf("unexpected status %d: %s", status, ctx.Response.Body())
+			}
+			return nil
+		}
+	})
+}
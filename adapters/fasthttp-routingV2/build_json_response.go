@@ -0,0 +1,16 @@
+package fasthttp_routing
+
+import (
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/vingarcia/kapi"
+)
+
+// BuildJSONResponse is a oneliner for 3 common actions:
+// (1) Marshal a struct or map into JSON;
+// (2) Set the response status;
+// (3) Set the content-type to `application/json`.
+//
+// It is a shortcut for `kapi.WriteJSON(New(ctx), statusCode, body)`.
+func BuildJSONResponse(ctx *routing.Context, statusCode int, body interface{}) error {
+	return kapi.WriteJSON(New(ctx), statusCode, body)
+}
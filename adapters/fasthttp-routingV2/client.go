@@ -1,6 +1,7 @@
 package fasthttp_routing
 
 import (
+	"log"
 	"reflect"
 
 	routing "github.com/jackwhelpton/fasthttp-routing/v2"
@@ -19,6 +20,7 @@ import (
 //	  PathArgument   int          `path:"my_path_arg"`
 //	  QueryArgument  uint64       `query:"my_query_arg"`
 //	  HeaderArgument string       `header:"my_header_arg"`
+//	  CookieArgument string       `cookie:"my_cookie"`
 //	  ContextValue   MyCustomType `context:"my_context_value"`
 //	  Body           MyCustomBody `content-type:"application/json"`
 //	}) error {
@@ -28,32 +30,150 @@ import (
 //	  return nil
 //	}
 //
+// The handler might also return a value besides the error, e.g.:
+//
+//	func MyAdaptedHandler(ctx *routing.Context, args MyArgs) (MyResponse, error)
+//
+// In this case the value is encoded as JSON and sent with a 200 status code.
+//
+// The first argument can also be a context.Context, which allows
+// the same handler to be used with any of the kapi adapters:
+//
+//	func MyAdaptedHandler(ctx context.Context, args MyArgs) error
+//
+// Optional kapi.Option values can be informed after the handler
+// for customizing the adapter, e.g. `kapi.WithErrorHandler(myErrorHandler)`.
+//
+// Since the type of the args struct is only known at runtime the handler
+// is called using reflection on every request, for routes where this cost
+// matters use AdaptT or AdaptTR instead, which call the handler directly.
+//
 // Note: all attributes in the input struct must be public or the adapter will panic
-func Adapt(fn interface{}) func(ctx *routing.Context) error {
+func Adapt(fn interface{}, opts ...adapter.Option) func(ctx *routing.Context) error {
+	handler, err := TryAdapt(fn, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return handler
+}
+
+// TryAdapt works as Adapt but instead of stopping the program when
+// the handler is invalid it returns a *kapi.HandlerError describing
+// every problem found on the handler and its args struct.
+func TryAdapt(fn interface{}, opts ...adapter.Option) (func(ctx *routing.Context) error, error) {
+	_, handler, err := adapt(fn, opts)
+	return handler, err
+}
+
+func adapt(fn interface{}, opts []adapter.Option) (adapter.DecodedHandlerFunction, func(ctx *routing.Context) error, error) {
 	fnType := reflect.TypeOf(fn)
 	fnValue := reflect.ValueOf(fn)
 
 	// The slow steps that heavily rely on reflection
 	// are done here once during startup in order to affect
 	// as little as possible the performance later on.
-	fnInfo := adapter.DecodeHandlerFunction(fnType, []reflect.Type{
+	fnInfo, err := adapter.TryDecodeHandlerFunction(fnType, []reflect.Type{
 		// These are the types of the arguments we expect the function to receive
 		// before the "args struct" which should always be the last argument.
 		//
-		// If the input function doesnt match this list the adapter will panic at startup.
+		// If the input function doesn't match this list an error is returned.
 		reflect.TypeOf(&routing.Context{}),
-	})
-	return func(ctx *routing.Context) error {
+	}, opts...)
+	if err != nil {
+		return fnInfo, nil, err
+	}
+
+	return fnInfo, func(ctx *routing.Context) error {
 		// This part uses cached information from `fnInfo` and uses
 		// reflection only to fill the struct making it more performatic:
-		inputStructPtr, err := adapter.UnmarshalRequestAsStruct(New(ctx), fnInfo)
+		request := New(ctx)
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := adapter.DecodeRequestInto(request, fnInfo, inputStructPtr.Elem())
 		if err != nil {
 			return err
 		}
 
+		firstArg := reflect.ValueOf(ctx)
+		if fnInfo.ReceivesContext() {
+			// The value is built with the interface type so reflection
+			// doesn't need to check if it implements context.Context:
+			reqCtx := request.GetContext()
+			firstArg = reflect.ValueOf(&reqCtx).Elem()
+		}
+
 		// Here we pass the arguments to the user defined handler function in the order
-		// we expect to receive them, i.e. `func(ctx *routing.Context, args MyStruct) error`:
-		err, _ = fnValue.Call([]reflect.Value{reflect.ValueOf(ctx), inputStructPtr.Elem()})[0].Interface().(error)
-		return err
+		// we expect to receive them, i.e. `func(ctx *routing.Context, args MyStruct) error`.
+		//
+		// Since the type of the args struct is only known at runtime the call
+		// has to use reflection, the handlers adapted with AdaptT and AdaptTR
+		// are called directly instead:
+		outputs := fnValue.Call([]reflect.Value{firstArg, inputStructPtr.Elem()})
+
+		// If the handler returns a value besides the error it is written as the response:
+		return adapter.WriteHandlerResponse(request, fnInfo, outputs)
+	}, nil
+}
+
+// AdaptT works exactly like Adapt but the shape of the handler
+// is checked at compile time, e.g.:
+//
+//	type MyArgs struct {
+//	  ID uint64 `path:"id"`
+//	}
+//
+//	router.Get("/users/:id", AdaptT(func(ctx *routing.Context, args MyArgs) error {
+//	  // ... handle request ...
+//	  return nil
+//	}))
+//
+// The Args type must still be a struct, and this is checked during startup.
+func AdaptT[Args any](fn func(ctx *routing.Context, args Args) error, opts ...adapter.Option) func(ctx *routing.Context) error {
+	fnInfo := adapter.DecodeHandlerFunction(reflect.TypeOf(fn), []reflect.Type{
+		reflect.TypeOf(&routing.Context{}),
+	}, opts...)
+	return func(ctx *routing.Context) error {
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := adapter.DecodeRequestInto(New(ctx), fnInfo, inputStructPtr.Elem())
+		if err != nil {
+			return err
+		}
+
+		// Since the types are known at compile time we can
+		// call the handler directly without using reflection:
+		return fn(ctx, *inputStructPtr.Interface().(*Args))
+	}
+}
+
+// AdaptTR works like AdaptT but for handlers that return a value
+// besides the error, which is written as the response, e.g.:
+//
+//	router.Get("/users/:id", AdaptTR(func(ctx *routing.Context, args MyArgs) (User, error) {
+//	  return usersRepo.Get(args.ID)
+//	}))
+func AdaptTR[Args any, Response any](fn func(ctx *routing.Context, args Args) (Response, error), opts ...adapter.Option) func(ctx *routing.Context) error {
+	fnInfo := adapter.DecodeHandlerFunction(reflect.TypeOf(fn), []reflect.Type{
+		reflect.TypeOf(&routing.Context{}),
+	}, opts...)
+	return func(ctx *routing.Context) error {
+		request := New(ctx)
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := adapter.DecodeRequestInto(request, fnInfo, inputStructPtr.Elem())
+		if err != nil {
+			return err
+		}
+
+		response, err := fn(ctx, *inputStructPtr.Interface().(*Args))
+		if err != nil {
+			return err
+		}
+
+		return adapter.WriteResponseValue(request, fnInfo, reflect.ValueOf(&response).Elem())
 	}
 }
@@ -0,0 +1,129 @@
+package fasthttp_routing
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/valyala/fasthttp"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type userArgs struct {
+	ID   uint64 `path:"id"`
+	Name string `query:"name,optional"`
+}
+
+type user struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestAdaptT(t *testing.T) {
+	router := routing.New()
+	router.Get("/users/<id>", AdaptT(func(ctx *routing.Context, args userArgs) error {
+		if args.Name == "fail" {
+			return errors.New("fake handler error")
+		}
+		ctx.SetBodyString(args.Name + " " + ctx.Param("id"))
+		return nil
+	}))
+
+	tests := []struct {
+		desc           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "should decode the args struct",
+			url:            "/users/42?name=fake-name",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "fake-name 42",
+		},
+		{
+			desc:           "should report decoding errors",
+			url:            "/users/not-a-number",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "could not convert path param 'id'",
+		},
+		{
+			desc:           "should return the errors of the handler",
+			url:            "/users/42?name=fail",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "fake handler error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			status, body := serveRequest(router, "GET", test.url)
+			tt.AssertEqual(t, status, test.expectedStatus)
+			tt.AssertContains(t, body, test.expectedBody)
+		})
+	}
+}
+
+func TestAdaptTR(t *testing.T) {
+	router := routing.New()
+	router.Get("/users/<id>", AdaptTR(func(ctx *routing.Context, args userArgs) (user, error) {
+		if args.Name == "fail" {
+			return user{}, errors.New("fake handler error")
+		}
+		return user{ID: args.ID, Name: args.Name}, nil
+	}))
+
+	type createdResponse struct {
+		Status   int    `status:""`
+		Location string `header:"Location"`
+		Body     user   `content-type:"application/json"`
+	}
+	router.Post("/users/<id>", AdaptTR(func(ctx *routing.Context, args userArgs) (createdResponse, error) {
+		return createdResponse{
+			Status:   http.StatusCreated,
+			Location: string(ctx.Path()),
+			Body:     user{ID: args.ID, Name: args.Name},
+		}, nil
+	}))
+
+	t.Run("should write the typed response as JSON", func(t *testing.T) {
+		status, body := serveRequest(router, "GET", "/users/42?name=fake-name")
+		tt.AssertEqual(t, status, http.StatusOK)
+		tt.AssertEqual(t, body, `{"id":42,"name":"fake-name"}`)
+	})
+
+	t.Run("should write the fields of response structs", func(t *testing.T) {
+		ctx := buildRequestCtx("POST", "/users/42?name=fake-name")
+		router.HandleRequest(ctx)
+
+		tt.AssertEqual(t, ctx.Response.StatusCode(), http.StatusCreated)
+		tt.AssertEqual(t, string(ctx.Response.Header.Peek("Location")), "/users/42")
+		tt.AssertEqual(t, string(ctx.Response.Body()), `{"id":42,"name":"fake-name"}`)
+	})
+
+	t.Run("should report decoding errors", func(t *testing.T) {
+		status, body := serveRequest(router, "GET", "/users/not-a-number")
+		tt.AssertEqual(t, status, http.StatusBadRequest)
+		tt.AssertContains(t, body, "could not convert path param 'id'")
+	})
+
+	t.Run("should return the errors of the handler without writing the response", func(t *testing.T) {
+		status, body := serveRequest(router, "GET", "/users/42?name=fail")
+		tt.AssertEqual(t, status, http.StatusInternalServerError)
+		tt.AssertEqual(t, body, "fake handler error")
+	})
+}
+
+// serveRequest serves the request with the router returning the status and the body of the response
+func serveRequest(router *routing.Router, method string, url string) (status int, body string) {
+	ctx := buildRequestCtx(method, url)
+	router.HandleRequest(ctx)
+	return ctx.Response.StatusCode(), string(ctx.Response.Body())
+}
+
+func buildRequestCtx(method string, url string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(url)
+	return &ctx
+}
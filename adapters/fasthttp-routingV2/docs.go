@@ -0,0 +1,28 @@
+package fasthttp_routing
+
+import (
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/docs"
+)
+
+// Docs returns a handler serving the OpenAPI document of the registry,
+// as JSON on "<prefix>/openapi.json" and as YAML on "<prefix>/openapi.yaml",
+// and a Swagger UI page for browsing it on any other path, e.g.:
+//
+//	docs := kapi.NewRegistry()
+//	fasthttp_routing.Get(router, "/users/<id>", GetUser, kapi.WithRegistry(docs))
+//
+//	router.Get("/docs<path:.*>", fasthttp_routing.Docs(docs, kapi.OpenAPIInfo{
+//	  Title:   "Users API",
+//	  Version: "1.0.0",
+//	}))
+//
+// The files of Swagger UI are served by the same handler, so the page
+// also works offline and the route must match any path under the prefix.
+func Docs(registry *kapi.Registry, info kapi.OpenAPIInfo) routing.Handler {
+	handler := docs.NewHandler(registry, info)
+	return func(ctx *routing.Context) error {
+		return handler.Serve(New(ctx))
+	}
+}
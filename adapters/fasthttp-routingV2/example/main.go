@@ -36,18 +36,17 @@ func main() {
 		Qparam string `query:"qparam,required"`
 		MyType MyType `context:"my_type"`
 		Body   Foo    `content-type:"application/json"`
-	}) error {
-		jsonResp, _ := json.Marshal(map[string]interface{}{
+	}) (map[string]interface{}, error) {
+		fmt.Println("here we are on the adapted route")
+
+		// The returned value is encoded as JSON and written as the response:
+		return map[string]interface{}{
 			"ID":        args.ID,
 			"Brand":     args.Brand,
 			"Query":     args.Qparam,
 			"UserValue": args.MyType,
 			"Body":      args.Body,
-		})
-		fmt.Println(string(jsonResp))
-		ctx.SetBody(jsonResp)
-
-		return nil
+		}, nil
 	}))
 
 	// This route does exactly the same as the route above
@@ -0,0 +1,112 @@
+package fasthttp_routing
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/vingarcia/kapi"
+)
+
+// Router is implemented by both *routing.Router and *routing.RouteGroup
+type Router interface {
+	To(methods, path string, handlers ...routing.Handler) *routing.Route
+}
+
+// Get adapts the handler and registers it on the router for the GET method,
+// see Route for more details.
+func Get(router Router, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	return Route(router, http.MethodGet, path, fn, opts...)
+}
+
+// Post adapts the handler and registers it on the router for the POST method,
+// see Route for more details.
+func Post(router Router, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	return Route(router, http.MethodPost, path, fn, opts...)
+}
+
+// Put adapts the handler and registers it on the router for the PUT method,
+// see Route for more details.
+func Put(router Router, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	return Route(router, http.MethodPut, path, fn, opts...)
+}
+
+// Patch adapts the handler and registers it on the router for the PATCH method,
+// see Route for more details.
+func Patch(router Router, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	return Route(router, http.MethodPatch, path, fn, opts...)
+}
+
+// Delete adapts the handler and registers it on the router for the DELETE method,
+// see Route for more details.
+func Delete(router Router, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	return Route(router, http.MethodDelete, path, fn, opts...)
+}
+
+// Route adapts the handler just like Adapt and registers it on the router,
+// but it also checks that every `path` tag on the args struct matches
+// one of the params of the route, e.g.:
+//
+//	fasthttp_routing.Route(router, "GET", "/users/<id>", func(ctx *routing.Context, args struct {
+//	  ID int `path:"user_id"`
+//	}) error {
+//	  // ...
+//	})
+//
+// Will stop the program during startup since the route has no `user_id` param.
+//
+// The params declared on the prefix of the group of the router are also checked.
+func Route(router Router, method string, path string, fn interface{}, opts ...kapi.Option) *routing.Route {
+	r, err := TryRoute(router, method, path, fn, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return r
+}
+
+// TryRoute works as Route but returns an error instead of
+// stopping the program when the handler is invalid.
+//
+// Since the params of the route are only checked after it is added to
+// the router, the router should not be used if an error is returned.
+func TryRoute(router Router, method string, path string, fn interface{}, opts ...kapi.Option) (*routing.Route, error) {
+	fnInfo, handler, err := adapt(fn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The route is registered first since only the path
+	// of the route includes the params of the prefix of its group:
+	route := router.To(method, path, handler)
+
+	err = kapi.CheckRouteParams(fnInfo, route.Path(), parseRouteParams(route.Path()))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range strings.Split(method, ",") {
+		kapi.RegisterRoute(fnInfo, m, openAPIPath(route.Path()))
+	}
+	return route, nil
+}
+
+// routeParamRegex matches params like `<id>` or `<id:\d+>`
+var routeParamRegex = regexp.MustCompile(`<([^:>]+)(:[^>]*)?>`)
+
+// parseRouteParams returns the names of the params of a fasthttp-routing route
+func parseRouteParams(path string) []string {
+	var params []string
+	for _, match := range routeParamRegex.FindAllStringSubmatch(path, -1) {
+		params = append(params, match[1])
+	}
+	return params
+}
+
+// openAPIPath converts a fasthttp-routing route into the syntax used
+// by OpenAPI, e.g. "/users/<id:\d+>" into "/users/{id}"
+func openAPIPath(path string) string {
+	return routeParamRegex.ReplaceAllString(path, "{$1}")
+}
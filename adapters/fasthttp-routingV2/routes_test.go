@@ -0,0 +1,58 @@
+package fasthttp_routing
+
+import (
+	"context"
+	"testing"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestTryRoute(t *testing.T) {
+	type args struct {
+		ID int `path:"id"`
+	}
+	handler := func(ctx context.Context, args args) error {
+		return nil
+	}
+
+	t.Run("should register the routes with the prefix of their groups", func(t *testing.T) {
+		docs := kapi.NewRegistry()
+		router := routing.New()
+		api := router.Group("/api/<org>")
+
+		_, err := TryRoute(router, "GET", "/users/<id>", handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+		_, err = TryRoute(api, "GET,POST", `/users/<id:\d+>`, handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+
+		var paths []string
+		for _, route := range docs.Routes() {
+			paths = append(paths, route.Method+" "+route.Path)
+		}
+		tt.AssertEqual(t, paths, []string{
+			"GET /users/{id}",
+			"GET /api/{org}/users/{id}",
+			"POST /api/{org}/users/{id}",
+		})
+	})
+
+	t.Run("should accept path tags reading the params of the group", func(t *testing.T) {
+		_, err := TryRoute(routing.New().Group("/api/<org>"), "GET", "/users/<id>", func(ctx context.Context, args struct {
+			Org string `path:"org"`
+			ID  int    `path:"id"`
+		}) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should report path tags missing on the route", func(t *testing.T) {
+		_, err := TryRoute(routing.New(), "GET", "/users/<user_id>", handler)
+		tt.AssertErrContains(t, err, "field ID reads the path param 'id' but the route '/users/<user_id>' has no such param")
+
+		_, err = TryRoute(routing.New().Group("/api/<org>"), "GET", "/users/<user_id>", handler)
+		tt.AssertErrContains(t, err, "field ID reads the path param 'id' but the route '/api/<org>/users/<user_id>' has no such param")
+	})
+}
@@ -0,0 +1,141 @@
+// Package routingtest runs kapi handlers through an actual fasthttp-routing
+// router, served in memory, using the requests built with the kapitest package,
+// so handlers receiving a *routing.Context can be tested just like the ones
+// receiving a context.Context:
+//
+//	resp, err := routingtest.Do(kapitest.Request().
+//	  Path("id", "42").
+//	  Header("Authorization", "Bearer fake-token"),
+//	  "/users/<id>", GetUser,
+//	)
+package routingtest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+
+	routing "github.com/jackwhelpton/fasthttp-routing/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"github.com/vingarcia/kapi"
+	adapter "github.com/vingarcia/kapi/adapters/fasthttp-routingV2"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+// Do adapts the handler with `fasthttp_routing.TryAdapt` and serves the request
+// with it, see Serve for more details. The error is only returned if the handler
+// is invalid or if there was a problem building or sending the request.
+func Do(b *kapitest.RequestBuilder, route string, fn interface{}, opts ...kapi.Option) (kapitest.Response, error) {
+	handler, err := adapter.TryAdapt(fn, opts...)
+	if err != nil {
+		return kapitest.Response{}, err
+	}
+
+	return Serve(b, route, handler)
+}
+
+// Serve registers the handler on a new router under the route, e.g. "/users/<id>",
+// and sends the request to a fasthttp server listening in memory, so the path params
+// are extracted by the router and the errors are handled just like it would.
+//
+// If the path params are informed with `RequestBuilder.Path()` they are written
+// on the route for building the path of the request, otherwise the path informed
+// with `RequestBuilder.URL()` is used. The values informed with
+// `RequestBuilder.Context()` are stored by a middleware before the handler runs.
+func Serve(b *kapitest.RequestBuilder, route string, handler routing.Handler) (kapitest.Response, error) {
+	httpReq, err := b.HTTPRequest()
+	if err != nil {
+		return kapitest.Response{}, err
+	}
+
+	if pathParams := b.PathParams(); len(pathParams) > 0 {
+		httpReq.URL.Path, err = buildPath(route, pathParams)
+		if err != nil {
+			return kapitest.Response{}, err
+		}
+	}
+
+	var handlerErr error
+	router := routing.New()
+	router.To(httpReq.Method, route, func(ctx *routing.Context) error {
+		request := adapter.New(ctx)
+		b.VisitContextValues(request.SetContextValue)
+		return nil
+	}, func(ctx *routing.Context) error {
+		handlerErr = handler(ctx)
+		return handlerErr
+	})
+
+	// An actual server is used since `RequestCtx.Done()`,
+	// and therefore `request.GetContext()`, depends on it:
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	go func() {
+		_ = fasthttp.Serve(ln, router.HandleRequest)
+	}()
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.Header.SetMethod(httpReq.Method)
+	req.SetRequestURI(httpReq.URL.String())
+	for key, values := range httpReq.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if httpReq.Body != nil {
+		body, err := io.ReadAll(httpReq.Body)
+		if err != nil {
+			return kapitest.Response{}, fmt.Errorf("routingtest: could not read the request body: %w", err)
+		}
+		req.SetBody(body)
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	client := fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) {
+			return ln.Dial()
+		},
+	}
+	err = client.Do(req, resp)
+	if err != nil {
+		return kapitest.Response{}, fmt.Errorf("routingtest: could not send the request: %w", err)
+	}
+
+	headers := http.Header{}
+	resp.Header.VisitAll(func(key []byte, value []byte) {
+		headers.Add(string(key), string(value))
+	})
+
+	return kapitest.Response{
+		StatusCode: resp.StatusCode(),
+		Headers:    headers,
+		Body:       append([]byte(nil), resp.Body()...),
+		Err:        handlerErr,
+	}, nil
+}
+
+// routeParamRegex matches params like `<id>` or `<id:\d+>`
+var routeParamRegex = regexp.MustCompile(`<([^:>]+)(:[^>]*)?>`)
+
+// buildPath writes the path params on the route
+func buildPath(route string, pathParams map[string]string) (string, error) {
+	var missing []string
+	path := routeParamRegex.ReplaceAllStringFunc(route, func(match string) string {
+		key := routeParamRegex.FindStringSubmatch(match)[1]
+		value, found := pathParams[key]
+		if !found {
+			missing = append(missing, key)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("routingtest: missing path params %v for route '%s'", missing, route)
+	}
+	return path, nil
+}
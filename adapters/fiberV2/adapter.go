@@ -1,12 +1,12 @@
 package fiber
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/vingarcia/kapi"
 )
 
-type any = interface{}
-
 // Adapter implements the kapi.RequestAdapter interface
 type Adapter struct {
 	ctx *fiber.Ctx
@@ -41,10 +41,117 @@ func (a Adapter) GetQueryParam(paramName string) string {
 	return a.ctx.Query(paramName)
 }
 
-func (a Adapter) GetContextValue(contextKey string) any {
-	return a.ctx.Context().Value(contextKey)
+func (a Adapter) GetCookie(cookieName string) string {
+	return a.ctx.Cookies(cookieName)
+}
+
+func (a Adapter) VisitHeaders(visitor func(key string, value string)) {
+	a.ctx.Context().Request.Header.VisitAll(func(key []byte, value []byte) {
+		visitor(string(key), string(value))
+	})
+}
+
+func (a Adapter) VisitQueryParams(visitor func(key string, value string)) {
+	a.ctx.Context().QueryArgs().VisitAll(func(key []byte, value []byte) {
+		visitor(string(key), string(value))
+	})
+}
+
+func (a Adapter) GetMethod() string {
+	return string(a.ctx.Context().Method())
+}
+
+func (a Adapter) GetPath() string {
+	return string(a.ctx.Context().Path())
+}
+
+func (a Adapter) GetHost() string {
+	return string(a.ctx.Context().Host())
+}
+
+func (a Adapter) GetScheme() string {
+	if a.ctx.Context().IsTLS() {
+		return "https"
+	}
+	return "http"
+}
+
+func (a Adapter) GetRemoteIP() string {
+	return a.ctx.Context().RemoteIP().String()
+}
+
+func (a Adapter) GetRawQuery() string {
+	return string(a.ctx.Context().URI().QueryString())
+}
+
+func (a Adapter) GetRequestURI() string {
+	return string(a.ctx.Context().RequestURI())
 }
 
-func (a Adapter) SetContextValue(contextKey string, value any) {
-	a.ctx.Context().Value(contextKey)
+// GetContext returns the context of the request, which is canceled when the
+// server shuts down, exposing the values stored with `ctx.Locals()` as string
+// keys and the values of the user context of fiber, i.e. `ctx.UserContext()`.
+func (a Adapter) GetContext() context.Context {
+	return requestContext{
+		Context: a.ctx.Context(),
+		ctx:     a.ctx,
+	}
+}
+
+func (a Adapter) GetContextValue(contextKey any) any {
+	if key, ok := contextKey.(string); ok {
+		return a.ctx.Locals(key)
+	}
+	return a.ctx.UserContext().Value(contextKey)
+}
+
+func (a Adapter) SetContextValue(contextKey any, value any) {
+	if key, ok := contextKey.(string); ok {
+		a.ctx.Locals(key, value)
+		return
+	}
+	a.ctx.SetUserContext(context.WithValue(a.ctx.UserContext(), contextKey, value))
+}
+
+func (a Adapter) SetStatus(statusCode int) {
+	a.ctx.Status(statusCode)
+}
+
+func (a Adapter) SetHeader(key string, value string) {
+	a.ctx.Set(key, value)
+}
+
+func (a Adapter) SetCookie(cookie kapi.Cookie) {
+	a.ctx.Cookie(&fiber.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		MaxAge:   cookie.MaxAge,
+		Expires:  cookie.Expires,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HTTPOnly,
+		SameSite: cookie.SameSite,
+	})
+}
+
+func (a Adapter) WriteBody(body []byte) error {
+	return a.ctx.Send(body)
+}
+
+// requestContext uses the deadline and the cancellation of the *fasthttp.RequestCtx,
+// merging the values stored with `ctx.Locals()` with the ones stored on the
+// user context of fiber.
+type requestContext struct {
+	context.Context
+	ctx *fiber.Ctx
+}
+
+func (r requestContext) Value(key any) any {
+	if key, ok := key.(string); ok {
+		if value := r.ctx.Locals(key); value != nil {
+			return value
+		}
+	}
+	return r.ctx.UserContext().Value(key)
 }
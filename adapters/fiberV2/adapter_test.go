@@ -0,0 +1,114 @@
+package fiber
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"github.com/vingarcia/kapi/adaptertest"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestAdapter(t *testing.T) {
+	adaptertest.Run(t, func(req adaptertest.Request, handler adaptertest.Handler) adaptertest.Response {
+		app := fiber.New()
+		route, url := buildRoute(req)
+		app.Add(req.Method, route, func(ctx *fiber.Ctx) error {
+			return handler(New(ctx))
+		})
+
+		httpReq := httptest.NewRequest(req.Method, url, bytes.NewReader(req.Body))
+		for key, value := range req.Headers {
+			httpReq.Header.Set(key, value)
+		}
+
+		resp, err := app.Test(httpReq, -1)
+		if err != nil {
+			t.Fatalf("unexpected error serving the request: %s", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error reading the response: %s", err)
+		}
+
+		return adaptertest.Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+			Body:       body,
+		}
+	})
+}
+
+func TestGetContext(t *testing.T) {
+	t.Run("should be canceled when the server shuts down", func(t *testing.T) {
+		started := make(chan struct{})
+		ctxErr := make(chan error, 1)
+		app := fiber.New()
+		app.Get("/test", func(ctx *fiber.Ctx) error {
+			reqCtx := New(ctx).GetContext()
+			close(started)
+
+			select {
+			case <-reqCtx.Done():
+				ctxErr <- reqCtx.Err()
+			case <-time.After(5 * time.Second):
+				ctxErr <- reqCtx.Err()
+			}
+			return nil
+		})
+
+		// The request is served by an actual server, listening in memory,
+		// since `RequestCtx.Done()` depends on the server that created it:
+		ln := fasthttputil.NewInmemoryListener()
+		go func() {
+			_ = app.Listener(ln)
+		}()
+
+		client := fasthttp.Client{
+			Dial: func(addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		}
+		go func() {
+			_, _, _ = client.Get(nil, "http://localhost/test")
+		}()
+
+		<-started
+		go func() {
+			_ = app.Shutdown()
+		}()
+
+		tt.AssertEqual(t, <-ctxErr, context.Canceled)
+	})
+}
+
+// buildRoute builds a route with one segment per path param of the request,
+// e.g. "/test/:id/:org", and the url matching it, e.g. "/test/42/fake-org?a=1"
+func buildRoute(req adaptertest.Request) (route string, url string) {
+	keys := make([]string, 0, len(req.PathParams))
+	for key := range req.PathParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	route, url = "/test", "/test"
+	for _, key := range keys {
+		route += "/:" + key
+		url += "/" + req.PathParams[key]
+	}
+
+	if req.RawQuery != "" {
+		url += "?" + req.RawQuery
+	}
+	return route, url
+}
@@ -0,0 +1,45 @@
+package fiber
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/vingarcia/kapi/adaptertest"
+)
+
+func BenchmarkFiber(b *testing.B) {
+	adaptertest.Benchmark(b, func(req adaptertest.Request, fn interface{}) func() error {
+		var handler fiber.Handler
+		if h, ok := fn.(adaptertest.Handler); ok {
+			handler = func(ctx *fiber.Ctx) error {
+				return h(New(ctx))
+			}
+		} else {
+			handler = Adapt(fn)
+		}
+
+		app := fiber.New()
+		route, url := buildRoute(req)
+		app.Add(req.Method, route, handler)
+
+		// The app handler is called directly with the same request
+		// so only the routing and the handler itself are measured:
+		serve := app.Handler()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(req.Method)
+		ctx.Request.SetRequestURI(url)
+		for key, value := range req.Headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		ctx.Request.SetBody(req.Body)
+
+		return func() error {
+			serve(ctx)
+			if status := ctx.Response.StatusCode(); status != fiber.StatusOK {
+				return fiber.NewError(status, string(ctx.Response.Body()))
+			}
+			return nil
+		}
+	})
+}
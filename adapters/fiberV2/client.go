@@ -1,6 +1,7 @@
 package fiber
 
 import (
+	"log"
 	"reflect"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,6 +20,7 @@ import (
 //	  PathArgument   int          `path:"my_path_arg"`
 //	  QueryArgument  uint64       `query:"my_query_arg"`
 //	  HeaderArgument string       `header:"my_header_arg"`
+//	  CookieArgument string       `cookie:"my_cookie"`
 //	  ContextValue   MyCustomType `context:"my_context_value"`
 //	  Body           MyCustomBody `content-type:"application/json"`
 //	}) error {
@@ -28,32 +30,150 @@ import (
 //	  return nil
 //	}
 //
+// The handler might also return a value besides the error, e.g.:
+//
+//	func MyAdaptedHandler(ctx *fiber.Ctx, args MyArgs) (MyResponse, error)
+//
+// In this case the value is encoded as JSON and sent with a 200 status code.
+//
+// The first argument can also be a context.Context, which allows
+// the same handler to be used with any of the kapi adapters:
+//
+//	func MyAdaptedHandler(ctx context.Context, args MyArgs) error
+//
+// Optional kapi.Option values can be informed after the handler
+// for customizing the adapter, e.g. `kapi.WithErrorHandler(myErrorHandler)`.
+//
+// Since the type of the args struct is only known at runtime the handler
+// is called using reflection on every request, for routes where this cost
+// matters use AdaptT or AdaptTR instead, which call the handler directly.
+//
 // Note: all attributes in the input struct must be public or the adapter will panic
-func Adapt(fn interface{}) func(ctx *fiber.Ctx) error {
+func Adapt(fn interface{}, opts ...kapi.Option) func(ctx *fiber.Ctx) error {
+	handler, err := TryAdapt(fn, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return handler
+}
+
+// TryAdapt works as Adapt but instead of stopping the program when
+// the handler is invalid it returns a *kapi.HandlerError describing
+// every problem found on the handler and its args struct.
+func TryAdapt(fn interface{}, opts ...kapi.Option) (func(ctx *fiber.Ctx) error, error) {
+	_, handler, err := adapt(fn, opts)
+	return handler, err
+}
+
+func adapt(fn interface{}, opts []kapi.Option) (kapi.DecodedHandlerFunction, func(ctx *fiber.Ctx) error, error) {
 	fnType := reflect.TypeOf(fn)
 	fnValue := reflect.ValueOf(fn)
 
 	// The slow steps that heavily rely on reflection
 	// are done here once during startup in order to affect
 	// as little as possible the performance later on.
-	fnInfo := kapi.DecodeHandlerFunction(fnType, []reflect.Type{
+	fnInfo, err := kapi.TryDecodeHandlerFunction(fnType, []reflect.Type{
 		// These are the types of the arguments we expect the function to receive
 		// before the "args struct" which should always be the last argument.
 		//
-		// If the input function doesn't match this list the adapter will panic at startup.
+		// If the input function doesn't match this list an error is returned.
 		reflect.TypeOf(&fiber.Ctx{}),
-	})
-	return func(ctx *fiber.Ctx) error {
+	}, opts...)
+	if err != nil {
+		return fnInfo, nil, err
+	}
+
+	return fnInfo, func(ctx *fiber.Ctx) error {
 		// This part uses cached information from `fnInfo` and uses
 		// reflection only to fill the struct making it more performatic:
-		inputStructPtr, err := kapi.UnmarshalRequestAsStruct(New(ctx), fnInfo)
+		request := New(ctx)
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := kapi.DecodeRequestInto(request, fnInfo, inputStructPtr.Elem())
 		if err != nil {
 			return err
 		}
 
+		firstArg := reflect.ValueOf(ctx)
+		if fnInfo.ReceivesContext() {
+			// The value is built with the interface type so reflection
+			// doesn't need to check if it implements context.Context:
+			reqCtx := request.GetContext()
+			firstArg = reflect.ValueOf(&reqCtx).Elem()
+		}
+
 		// Here we pass the arguments to the user defined handler function in the order
-		// we expect to receive them, i.e. `func(ctx *fiber.Ctx, args MyStruct) error`:
-		err, _ = fnValue.Call([]reflect.Value{reflect.ValueOf(ctx), inputStructPtr.Elem()})[0].Interface().(error)
-		return err
+		// we expect to receive them, i.e. `func(ctx *fiber.Ctx, args MyStruct) error`.
+		//
+		// Since the type of the args struct is only known at runtime the call
+		// has to use reflection, the handlers adapted with AdaptT and AdaptTR
+		// are called directly instead:
+		outputs := fnValue.Call([]reflect.Value{firstArg, inputStructPtr.Elem()})
+
+		// If the handler returns a value besides the error it is written as the response:
+		return kapi.WriteHandlerResponse(request, fnInfo, outputs)
+	}, nil
+}
+
+// AdaptT works exactly like Adapt but the shape of the handler
+// is checked at compile time, e.g.:
+//
+//	type MyArgs struct {
+//	  ID uint64 `path:"id"`
+//	}
+//
+//	router.Get("/users/:id", AdaptT(func(ctx *fiber.Ctx, args MyArgs) error {
+//	  // ... handle request ...
+//	  return nil
+//	}))
+//
+// The Args type must still be a struct, and this is checked during startup.
+func AdaptT[Args any](fn func(ctx *fiber.Ctx, args Args) error, opts ...kapi.Option) func(ctx *fiber.Ctx) error {
+	fnInfo := kapi.DecodeHandlerFunction(reflect.TypeOf(fn), []reflect.Type{
+		reflect.TypeOf(&fiber.Ctx{}),
+	}, opts...)
+	return func(ctx *fiber.Ctx) error {
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := kapi.DecodeRequestInto(New(ctx), fnInfo, inputStructPtr.Elem())
+		if err != nil {
+			return err
+		}
+
+		// Since the types are known at compile time we can
+		// call the handler directly without using reflection:
+		return fn(ctx, *inputStructPtr.Interface().(*Args))
+	}
+}
+
+// AdaptTR works like AdaptT but for handlers that return a value
+// besides the error, which is written as the response, e.g.:
+//
+//	router.Get("/users/:id", AdaptTR(func(ctx *fiber.Ctx, args MyArgs) (User, error) {
+//	  return usersRepo.Get(args.ID)
+//	}))
+func AdaptTR[Args any, Response any](fn func(ctx *fiber.Ctx, args Args) (Response, error), opts ...kapi.Option) func(ctx *fiber.Ctx) error {
+	fnInfo := kapi.DecodeHandlerFunction(reflect.TypeOf(fn), []reflect.Type{
+		reflect.TypeOf(&fiber.Ctx{}),
+	}, opts...)
+	return func(ctx *fiber.Ctx) error {
+		request := New(ctx)
+		inputStructPtr := fnInfo.AcquireArgs()
+		defer fnInfo.ReleaseArgs(inputStructPtr)
+
+		err := kapi.DecodeRequestInto(request, fnInfo, inputStructPtr.Elem())
+		if err != nil {
+			return err
+		}
+
+		response, err := fn(ctx, *inputStructPtr.Interface().(*Args))
+		if err != nil {
+			return err
+		}
+
+		return kapi.WriteResponseValue(request, fnInfo, reflect.ValueOf(&response).Elem())
 	}
 }
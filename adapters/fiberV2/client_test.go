@@ -0,0 +1,130 @@
+package fiber
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type userArgs struct {
+	ID   uint64 `path:"id"`
+	Name string `query:"name,optional"`
+}
+
+type user struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestAdaptT(t *testing.T) {
+	app := fiber.New()
+	app.Get("/users/:id", AdaptT(func(ctx *fiber.Ctx, args userArgs) error {
+		if args.Name == "fail" {
+			return errors.New("fake handler error")
+		}
+		return ctx.SendString(args.Name + " " + ctx.Params("id"))
+	}))
+
+	tests := []struct {
+		desc           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "should decode the args struct",
+			url:            "/users/42?name=fake-name",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "fake-name 42",
+		},
+		{
+			desc:           "should report decoding errors",
+			url:            "/users/not-a-number",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "could not convert path param 'id'",
+		},
+		{
+			desc:           "should return the errors of the handler",
+			url:            "/users/42?name=fail",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "fake handler error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			status, body := serveRequest(t, app, test.url)
+			tt.AssertEqual(t, status, test.expectedStatus)
+			tt.AssertContains(t, body, test.expectedBody)
+		})
+	}
+}
+
+func TestAdaptTR(t *testing.T) {
+	app := fiber.New()
+	app.Get("/users/:id", AdaptTR(func(ctx *fiber.Ctx, args userArgs) (user, error) {
+		if args.Name == "fail" {
+			return user{}, errors.New("fake handler error")
+		}
+		return user{ID: args.ID, Name: args.Name}, nil
+	}))
+
+	type createdResponse struct {
+		Status   int    `status:""`
+		Location string `header:"Location"`
+		Body     user   `content-type:"application/json"`
+	}
+	app.Post("/users/:id", AdaptTR(func(ctx *fiber.Ctx, args userArgs) (createdResponse, error) {
+		return createdResponse{
+			Status:   http.StatusCreated,
+			Location: ctx.Path(),
+			Body:     user{ID: args.ID, Name: args.Name},
+		}, nil
+	}))
+
+	t.Run("should write the typed response as JSON", func(t *testing.T) {
+		status, body := serveRequest(t, app, "/users/42?name=fake-name")
+		tt.AssertEqual(t, status, http.StatusOK)
+		tt.AssertEqual(t, body, `{"id":42,"name":"fake-name"}`)
+	})
+
+	t.Run("should write the fields of response structs", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/42?name=fake-name", nil), -1)
+		tt.AssertNoErr(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, resp.StatusCode, http.StatusCreated)
+		tt.AssertEqual(t, resp.Header.Get("Location"), "/users/42")
+		tt.AssertEqual(t, string(body), `{"id":42,"name":"fake-name"}`)
+	})
+
+	t.Run("should report decoding errors", func(t *testing.T) {
+		status, body := serveRequest(t, app, "/users/not-a-number")
+		tt.AssertEqual(t, status, http.StatusBadRequest)
+		tt.AssertContains(t, body, "could not convert path param 'id'")
+	})
+
+	t.Run("should return the errors of the handler without writing the response", func(t *testing.T) {
+		status, body := serveRequest(t, app, "/users/42?name=fail")
+		tt.AssertEqual(t, status, http.StatusInternalServerError)
+		tt.AssertEqual(t, body, "fake handler error")
+	})
+}
+
+// serveRequest sends a GET request to the app returning the status and the body of the response
+func serveRequest(t *testing.T, app *fiber.App, url string) (status int, body string) {
+	resp, err := app.Test(httptest.NewRequest("GET", url, nil), -1)
+	tt.AssertNoErr(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	tt.AssertNoErr(t, err)
+
+	return resp.StatusCode, string(b)
+}
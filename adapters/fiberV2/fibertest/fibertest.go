@@ -0,0 +1,122 @@
+// Package fibertest runs kapi handlers through an actual fiber app, in memory,
+// using the requests built with the kapitest package, so handlers receiving
+// a *fiber.Ctx can be tested just like the ones receiving a context.Context:
+//
+//	resp, err := fibertest.Do(kapitest.Request().
+//	  Path("id", "42").
+//	  Header("Authorization", "Bearer fake-token"),
+//	  "/users/:id", GetUser,
+//	)
+package fibertest
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	adapter "github.com/vingarcia/kapi/adapters/fiberV2"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+// Do adapts the handler with `fiber.TryAdapt` and serves the request with it,
+// see Serve for more details. The error is only returned if the handler
+// is invalid or if there was a problem building or sending the request.
+func Do(b *kapitest.RequestBuilder, route string, fn interface{}, opts ...kapi.Option) (kapitest.Response, error) {
+	handler, err := adapter.TryAdapt(fn, opts...)
+	if err != nil {
+		return kapitest.Response{}, err
+	}
+
+	return Serve(b, route, handler)
+}
+
+// Serve registers the handler on a new fiber app under the route, e.g. "/users/:id",
+// and sends the request to it with `app.Test()`, so the path params are extracted
+// by the router of fiber and the errors are handled just like fiber would.
+//
+// If the path params are informed with `RequestBuilder.Path()` they are written
+// on the route for building the path of the request, otherwise the path informed
+// with `RequestBuilder.URL()` is used. The values informed with
+// `RequestBuilder.Context()` are stored by a middleware before the handler runs.
+func Serve(b *kapitest.RequestBuilder, route string, handler fiber.Handler) (kapitest.Response, error) {
+	req, err := b.HTTPRequest()
+	if err != nil {
+		return kapitest.Response{}, err
+	}
+
+	if pathParams := b.PathParams(); len(pathParams) > 0 {
+		req.URL.Path, err = buildPath(route, pathParams)
+		if err != nil {
+			return kapitest.Response{}, err
+		}
+	}
+
+	var handlerErr error
+	app := fiber.New()
+	app.Add(req.Method, route, func(ctx *fiber.Ctx) error {
+		request := adapter.New(ctx)
+		b.VisitContextValues(request.SetContextValue)
+		return ctx.Next()
+	}, func(ctx *fiber.Ctx) error {
+		handlerErr = handler(ctx)
+		return handlerErr
+	})
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		return kapitest.Response{}, fmt.Errorf("fibertest: could not send the request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return kapitest.Response{}, fmt.Errorf("fibertest: could not read the response: %w", err)
+	}
+
+	return kapitest.Response{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       body,
+		Err:        handlerErr,
+	}, nil
+}
+
+// routeParamRegex matches the named params of fiber, e.g. `:id` or `:id?`,
+// and the greedy params `*` and `+`
+var routeParamRegex = regexp.MustCompile(`\\.|:([^?:/\-.]+)\??|[*+]`)
+
+// buildPath writes the path params on the route, the greedy params are
+// named "*" and "+" or "*1", "*2", etc. just like on `ctx.Params()`.
+func buildPath(route string, pathParams map[string]string) (string, error) {
+	counts := map[string]int{}
+	var missing []string
+	path := routeParamRegex.ReplaceAllStringFunc(route, func(match string) string {
+		if match[0] == '\\' {
+			return match[1:]
+		}
+
+		key := match
+		if match[0] == ':' {
+			key = routeParamRegex.FindStringSubmatch(match)[1]
+		} else {
+			counts[match]++
+			if _, found := pathParams[key]; !found || counts[match] > 1 {
+				key = match + strconv.Itoa(counts[match])
+			}
+		}
+
+		value, found := pathParams[key]
+		if !found && match[len(match)-1] != '?' {
+			missing = append(missing, key)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("fibertest: missing path params %v for route '%s'", missing, route)
+	}
+	return path, nil
+}
@@ -0,0 +1,165 @@
+package fibertest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+func TestDo(t *testing.T) {
+	type args struct {
+		ID     int    `path:"id"`
+		Filter string `query:"filter"`
+		Auth   string `header:"Authorization"`
+		User   string `context:"user"`
+	}
+
+	type idArgs struct {
+		ID int `path:"id"`
+	}
+
+	t.Run("should run handlers receiving a *fiber.Ctx", func(t *testing.T) {
+		var route string
+		var decoded args
+		resp, err := Do(kapitest.Request().
+			URL("/users/42?filter=active").
+			Header("Authorization", "Bearer fake").
+			Context("user", "fake-user"),
+			"/users/:id", func(ctx *fiber.Ctx, args args) (map[string]int, error) {
+				route = ctx.Route().Path
+				decoded = args
+				return map[string]int{"id": args.ID}, nil
+			},
+		)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertEqual(t, string(resp.Body), `{"id":42}`)
+		tt.AssertEqual(t, route, "/users/:id")
+		tt.AssertEqual(t, decoded, args{
+			ID:     42,
+			Filter: "active",
+			Auth:   "Bearer fake",
+			User:   "fake-user",
+		})
+	})
+
+	t.Run("should run handlers receiving a context.Context", func(t *testing.T) {
+		var decoded args
+		resp, err := Do(kapitest.Request().
+			Path("id", "42").
+			Query("filter", "active").
+			Header("Authorization", "Bearer fake").
+			Context("user", "fake-user"),
+			"/users/:id", func(ctx context.Context, args args) error {
+				decoded = args
+				return nil
+			},
+		)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertEqual(t, decoded, args{
+			ID:     42,
+			Filter: "active",
+			Auth:   "Bearer fake",
+			User:   "fake-user",
+		})
+	})
+
+	t.Run("should return the errors of the handler", func(t *testing.T) {
+		fakeErr := errors.New("fake error")
+		resp, err := Do(kapitest.Request().Path("id", "42"),
+			"/users/:id", func(ctx *fiber.Ctx, args idArgs) error {
+				return fakeErr
+			},
+		)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusInternalServerError)
+		tt.AssertEqual(t, string(resp.Body), "fake error")
+		tt.AssertEqual(t, resp.Err, fakeErr)
+	})
+
+	t.Run("should return decoding errors as bad requests", func(t *testing.T) {
+		resp, err := Do(kapitest.Request().Path("id", "not-a-number"),
+			"/users/:id", func(ctx *fiber.Ctx, args idArgs) error {
+				return nil
+			},
+		)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusBadRequest)
+		tt.AssertContains(t, string(resp.Body), "could not convert path param 'id' to int")
+	})
+
+	t.Run("should report invalid handlers", func(t *testing.T) {
+		_, err := Do(kapitest.Request(), "/", func(ctx *fiber.Ctx, args int) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "the last argument must be a struct!")
+	})
+}
+
+func TestBuildPath(t *testing.T) {
+	tests := []struct {
+		desc          string
+		route         string
+		pathParams    map[string]string
+		expectedPath  string
+		expectedError string
+	}{
+		{
+			desc:         "should write the named params",
+			route:        "/users/:id/posts/:post_id",
+			pathParams:   map[string]string{"id": "42", "post_id": "7"},
+			expectedPath: "/users/42/posts/7",
+		},
+		{
+			desc:         "should allow missing optional params",
+			route:        "/users/:id?",
+			pathParams:   map[string]string{"other": "1"},
+			expectedPath: "/users/",
+		},
+		{
+			desc:         "should write the greedy params",
+			route:        "/files/*/versions/*",
+			pathParams:   map[string]string{"*1": "a/b", "*2": "v1"},
+			expectedPath: "/files/a/b/versions/v1",
+		},
+		{
+			desc:         "should accept the short name of the first greedy param",
+			route:        "/files/*",
+			pathParams:   map[string]string{"*": "a/b"},
+			expectedPath: "/files/a/b",
+		},
+		{
+			desc:         "should keep escaped characters",
+			route:        `/api\:v1/users/:id`,
+			pathParams:   map[string]string{"id": "42"},
+			expectedPath: "/api:v1/users/42",
+		},
+		{
+			desc:          "should report missing params",
+			route:         "/users/:id/posts/:post_id",
+			pathParams:    map[string]string{"id": "42"},
+			expectedError: "missing path params [post_id] for route '/users/:id/posts/:post_id'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			path, err := buildPath(test.route, test.pathParams)
+			if test.expectedError != "" {
+				tt.AssertErrContains(t, err, test.expectedError)
+				return
+			}
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, path, test.expectedPath)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package fiber
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+)
+
+// Get adapts the handler and registers it on the router for the GET method,
+// see Route for more details.
+func Get(router fiber.Router, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	return Route(router, http.MethodGet, path, fn, opts...)
+}
+
+// Post adapts the handler and registers it on the router for the POST method,
+// see Route for more details.
+func Post(router fiber.Router, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	return Route(router, http.MethodPost, path, fn, opts...)
+}
+
+// Put adapts the handler and registers it on the router for the PUT method,
+// see Route for more details.
+func Put(router fiber.Router, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	return Route(router, http.MethodPut, path, fn, opts...)
+}
+
+// Patch adapts the handler and registers it on the router for the PATCH method,
+// see Route for more details.
+func Patch(router fiber.Router, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	return Route(router, http.MethodPatch, path, fn, opts...)
+}
+
+// Delete adapts the handler and registers it on the router for the DELETE method,
+// see Route for more details.
+func Delete(router fiber.Router, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	return Route(router, http.MethodDelete, path, fn, opts...)
+}
+
+// Route adapts the handler just like Adapt and registers it on the router,
+// but it also checks that every `path` tag on the args struct matches
+// one of the params of the route, e.g.:
+//
+//	fiber.Route(app, "GET", "/users/:id", func(ctx *fiber.Ctx, args struct {
+//	  ID int `path:"user_id"`
+//	}) error {
+//	  // ...
+//	})
+//
+// Will stop the program during startup since the route has no `user_id` param.
+//
+// The params declared on the prefix of the group of the router are also checked.
+func Route(router fiber.Router, method string, path string, fn interface{}, opts ...kapi.Option) fiber.Router {
+	r, err := TryRoute(router, method, path, fn, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return r
+}
+
+// TryRoute works as Route but returns an error instead of
+// stopping the program when the handler is invalid.
+//
+// Since the params of the route are only checked after it is added to
+// the router, the router should not be used if an error is returned.
+func TryRoute(router fiber.Router, method string, path string, fn interface{}, opts ...kapi.Option) (fiber.Router, error) {
+	fnInfo, handler, err := adapt(fn, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The route is registered first since only the registered
+	// path includes the params of the prefix of its group:
+	r := router.Add(method, path, handler)
+	fullPath := registeredPath(r, method, path)
+
+	err = kapi.CheckRouteParams(fnInfo, fullPath, parseRouteParams(fullPath))
+	if err != nil {
+		return nil, err
+	}
+
+	kapi.RegisterRoute(fnInfo, method, openAPIPath(fullPath))
+	return r, nil
+}
+
+// registeredPath returns the path of the route registered last for the method,
+// which includes the prefix of its group, since fiber doesn't expose the prefix
+// of a group but Group.Add returns the app with the route on its stack.
+func registeredPath(r fiber.Router, method string, path string) string {
+	app, ok := r.(*fiber.App)
+	if !ok {
+		return path
+	}
+
+	method = strings.ToUpper(method)
+	for _, routes := range app.Stack() {
+		if len(routes) > 0 && routes[len(routes)-1].Method == method {
+			return routes[len(routes)-1].Path
+		}
+	}
+	return path
+}
+
+// parseRouteParams returns the names of the params of a fiber route,
+// i.e. named params like `:id` and `:id?` and the greedy params `*` and `+`
+// which are accessible as "*" or "*1", "*2", etc.
+func parseRouteParams(path string) []string {
+	var params []string
+	var wildcardCount, plusCount int
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			// Skip escaped characters:
+			i++
+		case '*':
+			wildcardCount++
+			if wildcardCount == 1 {
+				params = append(params, "*")
+			}
+			params = append(params, "*"+strconv.Itoa(wildcardCount))
+		case '+':
+			plusCount++
+			if plusCount == 1 {
+				params = append(params, "+")
+			}
+			params = append(params, "+"+strconv.Itoa(plusCount))
+		case ':':
+			end := strings.IndexAny(path[i+1:], "?:/-.")
+			if end == -1 {
+				end = len(path) - i - 1
+			}
+			params = append(params, path[i+1:i+1+end])
+			i += end
+		}
+	}
+
+	return params
+}
+
+// openAPIPath converts a fiber route into the syntax used by OpenAPI,
+// e.g. "/users/:id" into "/users/{id}", the greedy params are named
+// "*" and "+" when they appear once, or "*1", "*2", etc. otherwise.
+func openAPIPath(path string) string {
+	wildcards := strings.Count(path, "*")
+	pluses := strings.Count(path, "+")
+
+	var b strings.Builder
+	var wildcardCount, plusCount int
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			// Escaped characters are kept without the escape:
+			if i+1 < len(path) {
+				i++
+				b.WriteByte(path[i])
+			}
+		case '*':
+			wildcardCount++
+			b.WriteString(greedyParamName("*", wildcardCount, wildcards))
+		case '+':
+			plusCount++
+			b.WriteString(greedyParamName("+", plusCount, pluses))
+		case ':':
+			end := strings.IndexAny(path[i+1:], "?:/-.")
+			if end == -1 {
+				end = len(path) - i - 1
+			}
+			b.WriteString("{" + path[i+1:i+1+end] + "}")
+			i += end
+			if i+1 < len(path) && path[i+1] == '?' {
+				i++
+			}
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+
+	return b.String()
+}
+
+func greedyParamName(symbol string, n int, total int) string {
+	if total == 1 {
+		return "{" + symbol + "}"
+	}
+	return "{" + symbol + strconv.Itoa(n) + "}"
+}
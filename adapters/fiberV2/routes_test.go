@@ -0,0 +1,64 @@
+package fiber
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestTryRoute(t *testing.T) {
+	type args struct {
+		ID int `path:"id"`
+	}
+	handler := func(ctx context.Context, args args) error {
+		return nil
+	}
+
+	t.Run("should register the routes with the prefix of their groups", func(t *testing.T) {
+		docs := kapi.NewRegistry()
+		app := fiber.New()
+		api := app.Group("/api/:org")
+		v1 := api.Group("/v1/")
+
+		_, err := TryRoute(app, "get", "/users/:id", handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+		_, err = TryRoute(api, "GET", "/users/:id", handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+		_, err = TryRoute(v1, "POST", "/users/:id/*", handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+		_, err = TryRoute(v1, "GET", "/users/:id", handler, kapi.WithRegistry(docs))
+		tt.AssertNoErr(t, err)
+
+		var paths []string
+		for _, route := range docs.Routes() {
+			paths = append(paths, route.Method+" "+route.Path)
+		}
+		tt.AssertEqual(t, paths, []string{
+			"GET /users/{id}",
+			"GET /api/{org}/users/{id}",
+			"POST /api/{org}/v1/users/{id}/{*}",
+			"GET /api/{org}/v1/users/{id}",
+		})
+	})
+
+	t.Run("should accept path tags reading the params of the group", func(t *testing.T) {
+		_, err := TryRoute(fiber.New().Group("/api/:org"), "GET", "/users/:id", func(ctx context.Context, args struct {
+			Org string `path:"org"`
+			ID  int    `path:"id"`
+		}) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+	})
+
+	t.Run("should report path tags missing on the route", func(t *testing.T) {
+		_, err := TryRoute(fiber.New(), "GET", "/users/:user_id", handler)
+		tt.AssertErrContains(t, err, "field ID reads the path param 'id' but the route '/users/:user_id' has no such param")
+
+		_, err = TryRoute(fiber.New().Group("/api/:org"), "GET", "/users/:user_id", handler)
+		tt.AssertErrContains(t, err, "field ID reads the path param 'id' but the route '/api/:org/users/:user_id' has no such param")
+	})
+}
@@ -0,0 +1,114 @@
+// Package adaptertest contains a conformance suite for implementations
+// of kapi.RequestAdapter, so third-party adapters can check that
+// they behave exactly like the official ones, e.g.:
+//
+//	func TestMyAdapter(t *testing.T) {
+//	  adaptertest.Run(t, func(req adaptertest.Request, handler adaptertest.Handler) adaptertest.Response {
+//	    // Build a request for your router using the components of req,
+//	    // serve it calling handler with your adapter in the middle of
+//	    // the request and return the response the client would receive.
+//	  })
+//	}
+package adaptertest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+)
+
+// Request contains the raw components of the requests used by the suite
+type Request struct {
+	Method string
+
+	// PathParams should be made available to the adapter
+	// as if they were params of the matched route
+	PathParams map[string]string
+
+	Headers map[string]string
+
+	// RawQuery is the query string without the leading "?"
+	RawQuery string
+
+	Body []byte
+}
+
+// Response contains the data received by the client
+type Response struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+}
+
+// Handler receives the adapter built for the current request,
+// errors should be handled just like the framework would handle
+// an error returned by an adapted handler.
+type Handler func(request kapi.RequestAdapter) error
+
+// Factory serves the request using the adapter being tested, calling the handler
+// once during the request and returning the response sent to the client.
+type Factory func(req Request, handler Handler) Response
+
+// Run runs all the conformance tests against the adapter built by the factory
+func Run(t *testing.T, factory Factory) {
+	t.Run("decoding", func(t *testing.T) {
+		testDecoding(t, factory)
+	})
+	t.Run("error paths", func(t *testing.T) {
+		testErrorPaths(t, factory)
+	})
+	t.Run("context values", func(t *testing.T) {
+		testContextValues(t, factory)
+	})
+	t.Run("request accessors", func(t *testing.T) {
+		testRequestAccessors(t, factory)
+	})
+	t.Run("responses", func(t *testing.T) {
+		testResponses(t, factory)
+	})
+}
+
+// serve calls the factory making sure the handler actually ran
+func serve(t *testing.T, factory Factory, req Request, handler Handler) Response {
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+
+	called := false
+	resp := factory(req, func(request kapi.RequestAdapter) error {
+		called = true
+		return handler(request)
+	})
+	if !called {
+		t.Fatalf("the factory returned without calling the handler")
+	}
+
+	return resp
+}
+
+// decode runs the kapi decoder using the adapter of the current request
+func decode(request kapi.RequestAdapter, args interface{}, opts ...kapi.Option) (interface{}, error) {
+	fnType := reflect.FuncOf(
+		[]reflect.Type{contextType, reflect.TypeOf(args)},
+		[]reflect.Type{errType},
+		false,
+	)
+
+	fnInfo, err := kapi.TryDecodeHandlerFunction(fnType, []reflect.Type{contextType}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := kapi.UnmarshalRequestAsStruct(request, fnInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Elem().Interface(), nil
+}
+
+var contextType = reflect.TypeOf(new(context.Context)).Elem()
+var errType = reflect.TypeOf(new(error)).Elem()
@@ -0,0 +1,84 @@
+package adaptertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+)
+
+// BenchmarkFactory prepares the request and returns a function that serves it
+// again each time it is called, it should reuse the request as much as the
+// framework allows so the benchmark measures only the handler.
+//
+// The fn argument is either an adaptertest.Handler, which should be called
+// directly with the adapter for the request, or a kapi handler receiving
+// a context.Context, which should be adapted with the Adapt function of
+// the adapter being measured.
+type BenchmarkFactory func(req Request, fn interface{}) (serve func() error)
+
+type benchmarkArgs struct {
+	ID    int    `path:"id"`
+	Brand string `header:"brand"`
+	Body  fakeBody
+}
+
+// Benchmark compares an adapted handler with the equivalent handler
+// reading the same values directly from the adapter, i.e. one integer
+// from the path, one header and a JSON body, reporting the allocations:
+//
+//	func BenchmarkMyAdapter(b *testing.B) {
+//	  adaptertest.Benchmark(b, func(req adaptertest.Request, fn interface{}) func() error {
+//	    // ...
+//	  })
+//	}
+func Benchmark(b *testing.B, factory BenchmarkFactory) {
+	req := Request{
+		Method:     "POST",
+		PathParams: map[string]string{"id": "42"},
+		Headers:    map[string]string{"brand": "fake-brand", "Content-Type": "application/json"},
+		Body:       []byte(`{"id":32,"name":"John Doe"}`),
+	}
+
+	var result benchmarkArgs
+	b.Run("adapted handler", func(b *testing.B) {
+		serve := factory(req, func(ctx context.Context, args benchmarkArgs) error {
+			result = args
+			return nil
+		})
+		runBenchmark(b, serve)
+	})
+
+	b.Run("not adapted handler", func(b *testing.B) {
+		serve := factory(req, Handler(func(request kapi.RequestAdapter) (err error) {
+			result.ID, err = strconv.Atoi(request.GetPathParam("id"))
+			if err != nil {
+				return err
+			}
+
+			result.Brand = request.GetHeaderParam("brand")
+			if result.Brand == "" {
+				return fmt.Errorf("missing brand header")
+			}
+
+			return json.Unmarshal(request.GetBody(), &result.Body)
+		}))
+		runBenchmark(b, serve)
+	})
+}
+
+func runBenchmark(b *testing.B, serve func() error) {
+	// The first call also checks the request is served without errors:
+	if err := serve(); err != nil {
+		b.Fatalf("unexpected error serving the request: %s", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = serve()
+	}
+}
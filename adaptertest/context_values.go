@@ -0,0 +1,172 @@
+package adaptertest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type typedKey struct{}
+
+// The assertions are always made after the request is served
+// since the handler might run on a goroutine other than the test's.
+func testContextValues(t *testing.T, factory Factory) {
+	t.Run("should read the values written with string keys", func(t *testing.T) {
+		var values []interface{}
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			values = append(values, request.GetContextValue("user"))
+
+			request.SetContextValue("user", "fakeUser")
+			values = append(values, request.GetContextValue("user"), request.GetContext().Value("user"))
+
+			request.SetContextValue("user", "otherUser")
+			values = append(values, request.GetContextValue("user"), request.GetContext().Value("user"))
+			return nil
+		})
+
+		tt.AssertEqual(t, values, []interface{}{nil, "fakeUser", "fakeUser", "otherUser", "otherUser"})
+	})
+
+	t.Run("should read the values written with typed keys", func(t *testing.T) {
+		var values []interface{}
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			values = append(values, request.GetContextValue(typedKey{}))
+
+			request.SetContextValue(typedKey{}, "fakeUser")
+			request.SetContextValue("user", "stringUser")
+			values = append(values, request.GetContextValue(typedKey{}), request.GetContext().Value(typedKey{}))
+
+			// String keys must not be affected by the typed ones:
+			values = append(values, request.GetContextValue("user"), request.GetContext().Value("user"))
+			return nil
+		})
+
+		tt.AssertEqual(t, values, []interface{}{nil, "fakeUser", "fakeUser", "stringUser", "stringUser"})
+	})
+
+	t.Run("should decode context values into the args struct", func(t *testing.T) {
+		type args struct {
+			User      string `context:"user"`
+			TypedUser string `context:"typed_user"`
+		}
+
+		var decoded interface{}
+		var err error
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetContextValue("user", "fakeUser")
+			request.SetContextValue(typedKey{}, "fakeTypedUser")
+
+			decoded, err = decode(request, args{}, kapi.WithContextKey("typed_user", typedKey{}))
+			return nil
+		})
+
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, decoded, args{
+			User:      "fakeUser",
+			TypedUser: "fakeTypedUser",
+		})
+	})
+
+	t.Run("should leave optional fields empty when the value is missing", func(t *testing.T) {
+		type args struct {
+			User    string  `context:"user,optional"`
+			UserPtr *string `context:"user_ptr,optional"`
+		}
+
+		var decoded interface{}
+		var err error
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			decoded, err = decode(request, args{})
+			return nil
+		})
+
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, decoded, args{})
+	})
+
+	t.Run("should fail when a value is missing", func(t *testing.T) {
+		var err error
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			_, err = decode(request, struct {
+				User string `context:"user"`
+			}{})
+			return nil
+		})
+
+		tt.AssertErrContains(t, err, "required context value 'user' is empty")
+	})
+
+	t.Run("should assign values to interface fields", func(t *testing.T) {
+		type args struct {
+			Stringer fmt.Stringer `context:"stringer"`
+			Any      interface{}  `context:"any"`
+		}
+
+		var decoded interface{}
+		var err error
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetContextValue("stringer", fakeStringer("fake"))
+			request.SetContextValue("any", 42)
+
+			decoded, err = decode(request, args{})
+			return nil
+		})
+
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, decoded, args{
+			Stringer: fakeStringer("fake"),
+			Any:      42,
+		})
+	})
+
+	t.Run("should handle pointer and value mismatches", func(t *testing.T) {
+		type user struct {
+			Name string
+		}
+		type args struct {
+			FromPtr user  `context:"user_ptr"`
+			ToPtr   *user `context:"user"`
+		}
+
+		var decoded args
+		var err error
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetContextValue("user_ptr", &user{Name: "fromPtr"})
+			request.SetContextValue("user", user{Name: "toPtr"})
+
+			var v interface{}
+			v, err = decode(request, args{})
+			decoded, _ = v.(args)
+			return nil
+		})
+
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, decoded.FromPtr, user{Name: "fromPtr"})
+		tt.AssertEqual(t, decoded.ToPtr, &user{Name: "toPtr"})
+	})
+
+	t.Run("should fail naming the field when the value has the wrong type", func(t *testing.T) {
+		type args struct {
+			User string `context:"user"`
+		}
+
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetContextValue("user", 42)
+
+			_, err := decode(request, args{})
+			return err
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusInternalServerError)
+		tt.AssertContains(t, string(resp.Body), "field User")
+	})
+}
+
+type fakeStringer string
+
+func (f fakeStringer) String() string {
+	return string(f)
+}
@@ -0,0 +1,276 @@
+package adaptertest
+
+import (
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type fakeBody struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type userID string
+
+func testDecoding(t *testing.T, factory Factory) {
+	tests := []struct {
+		desc          string
+		req           Request
+		args          interface{}
+		expectedValue interface{}
+	}{
+		{
+			desc: "should parse 1 param from path correctly",
+			req: Request{
+				PathParams: map[string]string{"param": "fake-path-param"},
+			},
+			args: struct {
+				P string `path:"param"`
+			}{},
+			expectedValue: struct {
+				P string `path:"param"`
+			}{P: "fake-path-param"},
+		},
+		{
+			desc: "should parse several path params correctly",
+			req: Request{
+				PathParams: map[string]string{"org": "fake-org", "id": "42"},
+			},
+			args: struct {
+				Org string `path:"org"`
+				ID  int    `path:"id"`
+			}{},
+			expectedValue: struct {
+				Org string `path:"org"`
+				ID  int    `path:"id"`
+			}{Org: "fake-org", ID: 42},
+		},
+		{
+			desc: "should parse 1 param from the header ignoring the case of the name",
+			req: Request{
+				Headers: map[string]string{"header-param": "fake-header-param"},
+			},
+			args: struct {
+				P string `header:"Header-Param"`
+			}{},
+			expectedValue: struct {
+				P string `header:"Header-Param"`
+			}{P: "fake-header-param"},
+		},
+		{
+			desc: "should parse 1 param from query correctly",
+			req: Request{
+				RawQuery: "query-param=fake-query-param",
+			},
+			args: struct {
+				P string `query:"query-param"`
+			}{},
+			expectedValue: struct {
+				P string `query:"query-param"`
+			}{P: "fake-query-param"},
+		},
+		{
+			desc: "should decode escaped query params",
+			req: Request{
+				RawQuery: "q=fake%20value%26more",
+			},
+			args: struct {
+				Q string `query:"q"`
+			}{},
+			expectedValue: struct {
+				Q string `query:"q"`
+			}{Q: "fake value&more"},
+		},
+		{
+			desc: "should parse cookies correctly",
+			req: Request{
+				Headers: map[string]string{"Cookie": "session=fake-session; theme=dark"},
+			},
+			args: struct {
+				Session string `cookie:"session"`
+				Theme   string `cookie:"theme"`
+			}{},
+			expectedValue: struct {
+				Session string `cookie:"session"`
+				Theme   string `cookie:"theme"`
+			}{Session: "fake-session", Theme: "dark"},
+		},
+		{
+			desc: "should parse the Body correctly",
+			req: Request{
+				Method: "POST",
+				Body:   []byte(`{"id":32,"name":"John Doe"}`),
+			},
+			args: struct {
+				Body fakeBody
+			}{},
+			expectedValue: struct {
+				Body fakeBody
+			}{Body: fakeBody{ID: 32, Name: "John Doe"}},
+		},
+		{
+			desc: "should parse raw bodies when the content-type is application/octet-stream",
+			req: Request{
+				Method: "POST",
+				Body:   []byte(`raw body`),
+			},
+			args: struct {
+				Body []byte `content-type:"application/octet-stream"`
+			}{},
+			expectedValue: struct {
+				Body []byte `content-type:"application/octet-stream"`
+			}{Body: []byte("raw body")},
+		},
+		{
+			desc: "should parse integers of every size correctly",
+			req: Request{
+				PathParams: map[string]string{"i": "-42"},
+				Headers:    map[string]string{"i8": "-8", "i16": "-16"},
+				RawQuery:   "i32=-32&i64=-64",
+			},
+			args: struct {
+				I   int   `path:"i"`
+				I8  int8  `header:"i8"`
+				I16 int16 `header:"i16"`
+				I32 int32 `query:"i32"`
+				I64 int64 `query:"i64"`
+			}{},
+			expectedValue: struct {
+				I   int   `path:"i"`
+				I8  int8  `header:"i8"`
+				I16 int16 `header:"i16"`
+				I32 int32 `query:"i32"`
+				I64 int64 `query:"i64"`
+			}{I: -42, I8: -8, I16: -16, I32: -32, I64: -64},
+		},
+		{
+			desc: "should parse unsigned integers of every size correctly",
+			req: Request{
+				PathParams: map[string]string{"u": "42"},
+				Headers:    map[string]string{"u8": "8", "u16": "16"},
+				RawQuery:   "u32=32&u64=18446744073709551615",
+			},
+			args: struct {
+				U   uint   `path:"u"`
+				U8  uint8  `header:"u8"`
+				U16 uint16 `header:"u16"`
+				U32 uint32 `query:"u32"`
+				U64 uint64 `query:"u64"`
+			}{},
+			expectedValue: struct {
+				U   uint   `path:"u"`
+				U8  uint8  `header:"u8"`
+				U16 uint16 `header:"u16"`
+				U32 uint32 `query:"u32"`
+				U64 uint64 `query:"u64"`
+			}{U: 42, U8: 8, U16: 16, U32: 32, U64: 18446744073709551615},
+		},
+		{
+			desc: "should parse named types correctly",
+			req: Request{
+				PathParams: map[string]string{"id": "fake-id"},
+			},
+			args: struct {
+				ID userID `path:"id"`
+			}{},
+			expectedValue: struct {
+				ID userID `path:"id"`
+			}{ID: "fake-id"},
+		},
+		{
+			desc: "should ignore optional params with no errors",
+			req:  Request{},
+			args: struct {
+				H int    `header:"h,optional"`
+				Q int    `query:"q"`
+				C string `cookie:"c,optional"`
+			}{},
+			expectedValue: struct {
+				H int    `header:"h,optional"`
+				Q int    `query:"q"`
+				C string `cookie:"c,optional"`
+			}{},
+		},
+		{
+			desc: "should use the default values for missing params",
+			req:  Request{},
+			args: struct {
+				H int    `header:"h" default:"42"`
+				Q string `query:"q" default:"fake-default"`
+			}{},
+			expectedValue: struct {
+				H int    `header:"h" default:"42"`
+				Q string `query:"q" default:"fake-default"`
+			}{H: 42, Q: "fake-default"},
+		},
+		{
+			desc: "should read the request metadata",
+			req: Request{
+				Method:     "PUT",
+				PathParams: map[string]string{"id": "42"},
+				RawQuery:   "a=1&b=2",
+				Headers:    map[string]string{"User-Agent": "fake-agent"},
+			},
+			args: struct {
+				ID        int    `path:"id"`
+				Method    string `request:"method"`
+				RawQuery  string `request:"raw_query"`
+				UserAgent string `request:"user_agent"`
+			}{},
+			expectedValue: struct {
+				ID        int    `path:"id"`
+				Method    string `request:"method"`
+				RawQuery  string `request:"raw_query"`
+				UserAgent string `request:"user_agent"`
+			}{ID: 42, Method: "PUT", RawQuery: "a=1&b=2", UserAgent: "fake-agent"},
+		},
+		{
+			desc: "should fill catch-all maps with every matching header and query param",
+			req: Request{
+				Headers:  map[string]string{"X-Meta-Foo": "foo", "X-Meta-Bar": "bar", "X-Other": "other"},
+				RawQuery: "attr.color=red&attr.size=10&other=1",
+			},
+			args: struct {
+				Meta  map[string]string `header:"X-Meta-*"`
+				Attrs map[string]string `query:"attr.*"`
+			}{},
+			expectedValue: struct {
+				Meta  map[string]string `header:"X-Meta-*"`
+				Attrs map[string]string `query:"attr.*"`
+			}{
+				Meta:  map[string]string{"X-Meta-Foo": "foo", "X-Meta-Bar": "bar"},
+				Attrs: map[string]string{"attr.color": "red", "attr.size": "10"},
+			},
+		},
+		{
+			desc: "should decode deepObject query params",
+			req: Request{
+				RawQuery: "filter[status]=active&filter[created_at][gte]=2024-01-01",
+			},
+			args: struct {
+				Filter map[string]string `query:"filter,deepObject"`
+			}{},
+			expectedValue: struct {
+				Filter map[string]string `query:"filter,deepObject"`
+			}{
+				Filter: map[string]string{"status": "active", "created_at[gte]": "2024-01-01"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var decoded interface{}
+			var err error
+			serve(t, factory, test.req, func(request kapi.RequestAdapter) error {
+				decoded, err = decode(request, test.args)
+				return nil
+			})
+
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, decoded, test.expectedValue)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package adaptertest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func testErrorPaths(t *testing.T, factory Factory) {
+	tests := []struct {
+		desc               string
+		req                Request
+		args               interface{}
+		expectedStatusCode int
+		expectedSource     string
+		expectedReason     kapi.ErrorReason
+		expectedMessage    string
+	}{
+		{
+			desc: "should report error when path param is empty",
+			req:  Request{},
+			args: struct {
+				P string `path:"param"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "path",
+			expectedReason:     kapi.ReasonMissingValue,
+			expectedMessage:    "path param 'param' is empty",
+		},
+		{
+			desc: "should report error when a required header is missing",
+			req:  Request{},
+			args: struct {
+				H string `header:"X-Fake"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "header",
+			expectedReason:     kapi.ReasonMissingValue,
+			expectedMessage:    "required header param 'X-Fake' is empty",
+		},
+		{
+			desc: "should report error when a required query param is missing",
+			req:  Request{},
+			args: struct {
+				Q string `query:"q,required"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "query",
+			expectedReason:     kapi.ReasonMissingValue,
+			expectedMessage:    "required query param 'q' is empty",
+		},
+		{
+			desc: "should report error when a required cookie is missing",
+			req:  Request{},
+			args: struct {
+				C string `cookie:"session"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "cookie",
+			expectedReason:     kapi.ReasonMissingValue,
+			expectedMessage:    "required cookie 'session' is empty",
+		},
+		{
+			desc: "should report error when a param has an invalid value",
+			req: Request{
+				PathParams: map[string]string{"id": "not-a-number"},
+			},
+			args: struct {
+				ID int `path:"id"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "path",
+			expectedReason:     kapi.ReasonInvalidValue,
+			expectedMessage:    "could not convert path param 'id' to int",
+		},
+		{
+			desc: "should report error when an integer overflows the field",
+			req: Request{
+				RawQuery: "n=300",
+			},
+			args: struct {
+				N uint8 `query:"n"`
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "query",
+			expectedReason:     kapi.ReasonInvalidValue,
+			expectedMessage:    "could not convert query param 'n' to uint8",
+		},
+		{
+			desc: "should report error when the body is not valid JSON",
+			req: Request{
+				Method: "POST",
+				Body:   []byte(`{"id":`),
+			},
+			args: struct {
+				Body fakeBody
+			}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSource:     "body",
+			expectedReason:     kapi.ReasonInvalidValue,
+			expectedMessage:    "could not parse body as JSON",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var decodingErr *kapi.DecodingError
+			resp := serve(t, factory, test.req, func(request kapi.RequestAdapter) error {
+				_, err := decode(request, test.args, kapi.WithErrorHandler(
+					func(request kapi.RequestAdapter, err *kapi.DecodingError) error {
+						decodingErr = err
+						return kapi.DefaultErrorHandler(request, err)
+					},
+				))
+				return err
+			})
+
+			if decodingErr == nil {
+				t.Fatalf("expected the error handler to be called, but it was not")
+			}
+			tt.AssertEqual(t, decodingErr.StatusCode, test.expectedStatusCode)
+			tt.AssertEqual(t, decodingErr.Source, test.expectedSource)
+			tt.AssertEqual(t, decodingErr.Reason, test.expectedReason)
+			tt.AssertErrContains(t, decodingErr, test.expectedMessage)
+
+			tt.AssertEqual(t, resp.StatusCode, test.expectedStatusCode)
+			tt.AssertContains(t, string(resp.Body), test.expectedMessage)
+		})
+	}
+
+	t.Run("should return the error built by the error handler", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			_, err := decode(request, struct {
+				H string `header:"X-Fake"`
+			}{}, kapi.WithErrorHandler(func(request kapi.RequestAdapter, err *kapi.DecodingError) error {
+				return request.NewHTTPError(http.StatusUnprocessableEntity, "custom: "+err.Key)
+			}))
+			return err
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusUnprocessableEntity)
+		tt.AssertContains(t, string(resp.Body), "custom: X-Fake")
+	})
+}
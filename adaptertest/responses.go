@@ -0,0 +1,241 @@
+package adaptertest
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func testResponses(t *testing.T, factory Factory) {
+	t.Run("should write the status, headers and body", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetStatus(http.StatusCreated)
+			request.SetHeader("Location", "/users/42")
+			request.SetHeader("Content-Type", "text/plain")
+			return request.WriteBody([]byte("fake body"))
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusCreated)
+		tt.AssertEqual(t, resp.Headers.Get("Location"), "/users/42")
+		tt.AssertContains(t, resp.Headers.Get("Content-Type"), "text/plain")
+		tt.AssertEqual(t, string(resp.Body), "fake body")
+	})
+
+	t.Run("should write JSON responses", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return kapi.WriteJSON(request, http.StatusAccepted, fakeBody{ID: 42, Name: "fake-name"})
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusAccepted)
+		tt.AssertContains(t, resp.Headers.Get("Content-Type"), "application/json")
+		tt.AssertEqual(t, string(resp.Body), `{"id":42,"name":"fake-name"}`)
+	})
+
+	t.Run("should not write the values that can't be encoded as JSON", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return kapi.WriteJSON(request, http.StatusOK, map[string]interface{}{
+				"token":    "fake-secret-token",
+				"callback": func() {},
+			})
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusInternalServerError)
+		tt.AssertContains(t, string(resp.Body), "could not marshal response body")
+		if strings.Contains(string(resp.Body), "fake-secret-token") {
+			t.Fatalf("expected the body not to be written but got: %s", string(resp.Body))
+		}
+	})
+
+	t.Run("should write cookies", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			request.SetCookie(kapi.Cookie{
+				Name:     "session",
+				Value:    "fake-session",
+				Path:     "/",
+				MaxAge:   60,
+				HTTPOnly: true,
+				SameSite: "Strict",
+			})
+			return nil
+		})
+
+		cookies := (&http.Response{Header: resp.Headers}).Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("expected 1 cookie but got: %v", resp.Headers["Set-Cookie"])
+		}
+		tt.AssertEqual(t, cookies[0].Name, "session")
+		tt.AssertEqual(t, cookies[0].Value, "fake-session")
+		tt.AssertEqual(t, cookies[0].Path, "/")
+		tt.AssertEqual(t, cookies[0].MaxAge, 60)
+		tt.AssertEqual(t, cookies[0].HttpOnly, true)
+		tt.AssertEqual(t, cookies[0].SameSite, http.SameSiteStrictMode)
+	})
+
+	t.Run("should write the fields of response structs", func(t *testing.T) {
+		type statusCode int
+		type response struct {
+			Status   statusCode   `status:""`
+			Location string       `header:"Location"`
+			Count    int          `header:"X-Count"`
+			Empty    string       `header:"X-Empty"`
+			Theme    string       `cookie:"theme"`
+			Session  kapi.Cookie  `cookie:"session"`
+			Missing  *kapi.Cookie `cookie:"missing"`
+			Body     fakeBody     `content-type:"application/json"`
+		}
+
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return writeResponse(request, response{
+				Status:   http.StatusCreated,
+				Location: "/users/42",
+				Count:    7,
+				Theme:    "dark",
+				Session:  kapi.Cookie{Value: "fake-session", Path: "/"},
+				Body:     fakeBody{ID: 42, Name: "fake-name"},
+			})
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusCreated)
+		tt.AssertEqual(t, resp.Headers.Get("Location"), "/users/42")
+		tt.AssertEqual(t, resp.Headers.Get("X-Count"), "7")
+		tt.AssertEqual(t, resp.Headers.Values("X-Empty"), []string(nil))
+		tt.AssertContains(t, resp.Headers.Get("Content-Type"), "application/json")
+		tt.AssertEqual(t, string(resp.Body), `{"id":42,"name":"fake-name"}`)
+
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range (&http.Response{Header: resp.Headers}).Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		tt.AssertEqual(t, len(cookies), 2)
+		tt.AssertEqual(t, cookies["theme"].Value, "dark")
+		tt.AssertEqual(t, cookies["session"].Value, "fake-session")
+		tt.AssertEqual(t, cookies["session"].Path, "/")
+	})
+
+	t.Run("should use 200 when the status field is empty", func(t *testing.T) {
+		type response struct {
+			Status int    `status:""`
+			Body   []byte `content-type:"text/plain"`
+		}
+
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return writeResponse(request, response{Body: []byte("fake body")})
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertContains(t, resp.Headers.Get("Content-Type"), "text/plain")
+		tt.AssertEqual(t, string(resp.Body), "fake body")
+	})
+
+	t.Run("should encode structs with an untagged Body field as the body", func(t *testing.T) {
+		type comment struct {
+			ID   int    `json:"id"`
+			Body string `json:"body"`
+		}
+
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return writeResponse(request, comment{ID: 1, Body: "hi"})
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertContains(t, resp.Headers.Get("Content-Type"), "application/json")
+		tt.AssertEqual(t, string(resp.Body), `{"id":1,"body":"hi"}`)
+	})
+
+	t.Run("should build HTTP errors with the informed status and message", func(t *testing.T) {
+		resp := serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			return request.NewHTTPError(http.StatusTeapot, "fake error message")
+		})
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusTeapot)
+		tt.AssertContains(t, string(resp.Body), "fake error message")
+	})
+}
+
+// writeResponse writes the response as if it was returned by a handler
+func writeResponse(request kapi.RequestAdapter, response interface{}) error {
+	fnType := reflect.FuncOf(
+		[]reflect.Type{contextType, reflect.TypeOf(struct{}{})},
+		[]reflect.Type{reflect.TypeOf(response), errType},
+		false,
+	)
+
+	fnInfo, err := kapi.TryDecodeHandlerFunction(fnType, []reflect.Type{contextType})
+	if err != nil {
+		return err
+	}
+
+	return kapi.WriteResponseValue(request, fnInfo, reflect.ValueOf(response))
+}
+
+func testRequestAccessors(t *testing.T, factory Factory) {
+	t.Run("should visit every header and query param", func(t *testing.T) {
+		var headers, queryParams []string
+		serve(t, factory, Request{
+			Headers:  map[string]string{"X-Fake-A": "a", "X-Fake-B": "b"},
+			RawQuery: "a=1&b=2",
+		}, func(request kapi.RequestAdapter) error {
+			request.VisitHeaders(func(key, value string) {
+				if key == "X-Fake-A" || key == "X-Fake-B" {
+					headers = append(headers, key+"="+value)
+				}
+			})
+			request.VisitQueryParams(func(key, value string) {
+				queryParams = append(queryParams, key+"="+value)
+			})
+			return nil
+		})
+
+		sort.Strings(headers)
+		sort.Strings(queryParams)
+		tt.AssertEqual(t, headers, []string{"X-Fake-A=a", "X-Fake-B=b"})
+		tt.AssertEqual(t, queryParams, []string{"a=1", "b=2"})
+	})
+
+	t.Run("should return the raw components of the request", func(t *testing.T) {
+		var method, path, rawQuery, requestURI, scheme string
+		var body []byte
+		serve(t, factory, Request{
+			Method:     "POST",
+			PathParams: map[string]string{"id": "42"},
+			RawQuery:   "a=1",
+			Body:       []byte("fake body"),
+		}, func(request kapi.RequestAdapter) error {
+			method = request.GetMethod()
+			path = request.GetPath()
+			rawQuery = request.GetRawQuery()
+			requestURI = request.GetRequestURI()
+			scheme = request.GetScheme()
+			body = request.GetBody()
+			return nil
+		})
+
+		tt.AssertEqual(t, method, "POST")
+		tt.AssertContains(t, path, "42")
+		tt.AssertEqual(t, rawQuery, "a=1")
+		tt.AssertEqual(t, requestURI, path+"?a=1")
+		tt.AssertEqual(t, scheme, "http")
+		tt.AssertEqual(t, string(body), "fake body")
+	})
+
+	t.Run("should return a live context for the request", func(t *testing.T) {
+		var ctxErr error
+		var hasContext bool
+		serve(t, factory, Request{}, func(request kapi.RequestAdapter) error {
+			ctx := request.GetContext()
+			hasContext = ctx != nil
+			if hasContext {
+				ctxErr = ctx.Err()
+			}
+			return nil
+		})
+
+		tt.AssertEqual(t, hasContext, true)
+		tt.AssertNoErr(t, ctxErr)
+	})
+}
@@ -3,27 +3,37 @@ package kapi
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
-
-	routing "github.com/jackwhelpton/fasthttp-routing/v2"
 )
 
-// BuildJSONResponse is a oneliner for 3 common actions:
+// WriteJSON is a oneliner for 3 common actions:
 // (1) Marshal a struct or map into JSON;
 // (2) Set the response status;
 // (3) Set the content-type to `application/json`.
-func BuildJSONResponse(ctx *routing.Context, statusCode int, body interface{}) error {
+//
+// It works with any RequestAdapter, e.g.:
+//
+//	return kapi.WriteJSON(fiber.New(ctx), http.StatusOK, user)
+//
+// If the body can't be marshaled nothing is written
+// and an error with status 500 is returned instead,
+// the body itself is only written to the logs.
+//
+// This function replaces the old `kapi.BuildJSONResponse`, which
+// is now available on the adapters, e.g. `fiber.BuildJSONResponse`.
+func WriteJSON(adapter RequestAdapter, statusCode int, body interface{}) error {
 	rawJSON, err := json.Marshal(body)
 	if err != nil {
-		return routing.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
-			"could not marshal response body, Reason: %s, Body: %v",
-			err.Error(),
-			body,
+		// The body is only logged since it might contain
+		// information that should not be sent to the client:
+		log.Printf("kapi: could not marshal response body, Reason: %s, Body: %v", err.Error(), body)
+		return adapter.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf(
+			"could not marshal response body: %s", err.Error(),
 		))
 	}
 
-	ctx.SetStatusCode(statusCode)
-	ctx.SetContentType("application/json")
-	ctx.SetBody(rawJSON)
-	return nil
+	adapter.SetStatus(statusCode)
+	adapter.SetHeader("Content-Type", "application/json")
+	return adapter.WriteBody(rawJSON)
 }
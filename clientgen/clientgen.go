@@ -0,0 +1,745 @@
+// Package clientgen generates typed Go clients for the routes of a kapi.Registry.
+//
+// Each route becomes a method of the generated client receiving the same args
+// struct used by its handler, and the request is built from the same tags the
+// handler uses for decoding it, e.g. for a route registered with:
+//
+//	fiber.Get(app, "/users/:id", GetUser, kapi.WithRegistry(docs), kapi.WithOperationID("GetUser"))
+//
+// The generated client has the method:
+//
+//	func (c *Client) GetUser(ctx context.Context, args users.GetUserArgs) (users.User, error)
+//
+// Since the registry is only available at runtime the generator is usually
+// called by a small program that registers the routes of the service, and
+// that is invoked with `go generate`.
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/vingarcia/kapi"
+)
+
+const generatedHeader = "// Code generated by kapi clientgen. DO NOT EDIT."
+
+// Options customizes the generated code
+type Options struct {
+	// Package is the name of the package of the generated file, it is required
+	Package string
+
+	// PackagePath is the import path of the package of the generated file,
+	// it is only necessary if the args structs are declared on that package
+	PackagePath string
+}
+
+// Generate returns the source code of a client with one method per route of the registry.
+//
+// The methods are named after the operation ids informed with `kapi.WithOperationID`,
+// or after the method and the path of the route otherwise, e.g. "GetUsersByID" for
+// "GET /users/{id}". The args structs and the response types must be named types.
+//
+// Only the values received from the clients are sent, i.e. the `path`, `header`,
+// `query` and `cookie` params and the body, and the optional params are omitted
+// when they are empty following the same rules as kapi.EncodeRequest, see
+// kapi.ParamSpec.OmitsZeroValue.
+func Generate(registry *kapi.Registry, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("the name of the package of the generated client is required")
+	}
+
+	g := generator{
+		opts:    opts,
+		imports: map[string]string{},
+		methods: map[string]string{},
+	}
+	for _, route := range registry.Routes() {
+		err := g.addRoute(route)
+		if err != nil {
+			return nil, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+		}
+	}
+
+	return g.source()
+}
+
+type generator struct {
+	opts Options
+
+	// imports maps the import paths to their names
+	imports map[string]string
+
+	// methods maps the names of the methods to the routes using them
+	methods map[string]string
+
+	needsCookieHelper bool
+
+	body bytes.Buffer
+}
+
+// reservedNames are the variables and the packages of the standard library
+// used on the generated code, the other packages must not be named after them
+var reservedNames = map[string]bool{
+	"args": true, "ctx": true, "c": true, "req": true, "resp": true, "respBody": true,
+	"endpoint": true, "payload": true, "err": true, "response": true, "query": true,
+	"k": true, "v": true, "vs": true, "n": true, "cookie": true, "value": true, "kapiCookie": true,
+
+	"bytes": true, "context": true, "fmt": true, "io": true, "http": true,
+	"json": true, "strconv": true, "strings": true, "url": true,
+}
+
+var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (g *generator) addRoute(route kapi.RegisteredRoute) error {
+	fnInfo := route.Handler
+
+	name := fnInfo.OperationID()
+	if name == "" {
+		name = strings.ToLower(route.Method) + " " + route.Path
+	}
+	name = identifier(name)
+	if other, found := g.methods[name]; found {
+		return fmt.Errorf(
+			"the method name %s is also used by the route %s, use kapi.WithOperationID for naming the routes",
+			name, other,
+		)
+	}
+	g.methods[name] = route.Method + " " + route.Path
+
+	if fnInfo.ArgsType().Name() == "" {
+		return fmt.Errorf("the args struct must be a named type for being used on the client")
+	}
+	argsType, err := g.typeString(fnInfo.ArgsType())
+	if err != nil {
+		return err
+	}
+
+	var responseType string
+	zeroReturn := ""
+	if fnInfo.ResponseType() != nil {
+		responseType, err = g.typeString(fnInfo.ResponseType())
+		if err != nil {
+			return err
+		}
+		zeroReturn = "response, "
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// %s calls %s %s\n", name, route.Method, route.Path)
+	if responseType == "" {
+		fmt.Fprintf(&buf, "func (c *Client) %s(ctx context.Context, args %s) error {\n", name, argsType)
+	} else {
+		fmt.Fprintf(&buf, "func (c *Client) %s(ctx context.Context, args %s) (%s, error) {\n", name, argsType, responseType)
+		fmt.Fprintf(&buf, "\tvar response %s\n\n", responseType)
+	}
+	g.use("context")
+
+	params := fnInfo.Params()
+	pathExpr, err := g.pathExpr(route.Path, params)
+	if err != nil {
+		return err
+	}
+
+	hasQuery := false
+	for _, param := range params {
+		hasQuery = hasQuery || param.Source == "query"
+	}
+	if hasQuery {
+		g.use("net/url")
+		fmt.Fprintf(&buf, "\tquery := url.Values{}\n")
+		for _, param := range params {
+			if param.Source == "query" {
+				g.writeParam(&buf, param, "query.Set", "query.Add")
+			}
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+
+	fmt.Fprintf(&buf, "\tendpoint := c.BaseURL + %s\n", pathExpr)
+	if hasQuery {
+		fmt.Fprintf(&buf, "\tif len(query) > 0 {\n\t\tendpoint += \"?\" + query.Encode()\n\t}\n")
+	}
+	fmt.Fprintf(&buf, "\n")
+
+	g.use("net/http")
+	body := fnInfo.Body()
+	switch {
+	case body == nil:
+		fmt.Fprintf(&buf, "\treq, err := http.NewRequestWithContext(ctx, %q, endpoint, nil)\n", route.Method)
+	case body.ContentType == "application/octet-stream":
+		g.use("bytes")
+		fmt.Fprintf(&buf, "\treq, err := http.NewRequestWithContext(ctx, %q, endpoint, bytes.NewReader(args.%s))\n", route.Method, body.Field)
+	default:
+		g.use("bytes")
+		g.use("encoding/json")
+		fmt.Fprintf(&buf, "\tpayload, err := json.Marshal(args.%s)\n", body.Field)
+		fmt.Fprintf(&buf, "\tif err != nil {\n\t\treturn %serr\n\t}\n", zeroReturn)
+		fmt.Fprintf(&buf, "\treq, err := http.NewRequestWithContext(ctx, %q, endpoint, bytes.NewReader(payload))\n", route.Method)
+	}
+	fmt.Fprintf(&buf, "\tif err != nil {\n\t\treturn %serr\n\t}\n", zeroReturn)
+	if body != nil {
+		fmt.Fprintf(&buf, "\treq.Header.Set(\"Content-Type\", %q)\n", body.ContentType)
+	}
+
+	for _, param := range params {
+		switch param.Source {
+		case "header":
+			g.writeParam(&buf, param, "req.Header.Set", "req.Header.Add")
+		case "cookie":
+			value := g.formatValue(param.Type, "args."+param.Field)
+			g.writeGuarded(&buf, param, fmt.Sprintf("req.AddCookie(&http.Cookie{Name: %q, Value: %s})", param.Key, value))
+		}
+	}
+	fmt.Fprintf(&buf, "\n")
+
+	if fnInfo.ResponseType() == nil {
+		fmt.Fprintf(&buf, "\t_, _, err = c.do(req)\n\treturn err\n}\n")
+		g.body.Write(buf.Bytes())
+		return nil
+	}
+
+	err = g.writeResponse(&buf, fnInfo.ResponseType())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, "\n\treturn response, nil\n}\n")
+
+	g.body.Write(buf.Bytes())
+	return nil
+}
+
+// pathExpr returns the expression building the path of the route
+// with the path params of the args struct, e.g.: "/users/" + url.PathEscape(args.ID)
+func (g *generator) pathExpr(routePath string, params []kapi.ParamSpec) (string, error) {
+	pathParams := map[string]kapi.ParamSpec{}
+	for _, param := range params {
+		if param.Source == "path" {
+			pathParams[param.Key] = param
+		}
+	}
+
+	var parts []string
+	last := 0
+	for _, match := range pathParamRegex.FindAllStringSubmatchIndex(routePath, -1) {
+		if match[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", routePath[last:match[0]]))
+		}
+		last = match[1]
+
+		key := routePath[match[2]:match[3]]
+		param, found := pathParams[key]
+		if !found {
+			return "", fmt.Errorf("the path param '%s' is not read by any field of the args struct", key)
+		}
+		delete(pathParams, key)
+
+		value := g.formatValue(param.Type, "args."+param.Field)
+		if strings.HasPrefix(key, "*") || strings.HasPrefix(key, "+") {
+			// Greedy params might contain slashes so they are not escaped:
+			parts = append(parts, value)
+			continue
+		}
+		g.use("net/url")
+		parts = append(parts, "url.PathEscape("+value+")")
+	}
+	if last < len(routePath) {
+		parts = append(parts, fmt.Sprintf("%q", routePath[last:]))
+	}
+
+	for key, param := range pathParams {
+		return "", fmt.Errorf("field %s reads the path param '%s' which is not present on the route", param.Field, key)
+	}
+
+	if len(parts) == 0 {
+		return `""`, nil
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// writeParam writes the code adding a header or query param using
+// the set function, or the add function for multi value catch-all params.
+func (g *generator) writeParam(buf *bytes.Buffer, param kapi.ParamSpec, set string, add string) {
+	expr := "args." + param.Field
+	switch {
+	case param.CatchAll:
+		fmt.Fprintf(buf, "\tfor k, v := range %s {\n", expr)
+		if param.Type.Elem().Kind() == reflect.Slice {
+			fmt.Fprintf(buf, "\t\tfor _, vs := range v {\n\t\t\t%s(string(k), string(vs))\n\t\t}\n", add)
+		} else {
+			fmt.Fprintf(buf, "\t\t%s(string(k), string(v))\n", set)
+		}
+		fmt.Fprintf(buf, "\t}\n")
+	case param.DeepObject:
+		g.writeDeepObject(buf, param, param.Key, set)
+	default:
+		g.writeGuarded(buf, param, fmt.Sprintf("%s(%q, %s)", set, param.Key, g.formatValue(param.Type, expr)))
+	}
+}
+
+// writeDeepObject writes the fields of the deepObject
+// using the bracket notation, e.g. `filter[status]=active`
+func (g *generator) writeDeepObject(buf *bytes.Buffer, param kapi.ParamSpec, key string, set string) {
+	if param.Fields == nil {
+		fmt.Fprintf(buf, "\tfor k, v := range args.%s {\n\t\t%s(%q+string(k)+\"]\", string(v))\n\t}\n", param.Field, set, key+"[")
+		return
+	}
+
+	for _, field := range param.Fields {
+		fieldKey := key + "[" + field.Key + "]"
+		if field.DeepObject {
+			g.writeDeepObject(buf, field, fieldKey, set)
+			continue
+		}
+		g.writeGuarded(buf, field, fmt.Sprintf("%s(%q, %s)", set, fieldKey, g.formatValue(field.Type, "args."+field.Field)))
+	}
+}
+
+// writeGuarded writes the statement, checking if the value is not empty
+// before sending it if the param is omitted when empty, which follows
+// the same rules as kapi.EncodeRequest, see ParamSpec.OmitsZeroValue
+func (g *generator) writeGuarded(buf *bytes.Buffer, param kapi.ParamSpec, stmt string) {
+	if !param.OmitsZeroValue() {
+		fmt.Fprintf(buf, "\t%s\n", stmt)
+		return
+	}
+
+	zero := "0"
+	if param.Type.Kind() == reflect.String {
+		zero = `""`
+	}
+	fmt.Fprintf(buf, "\tif args.%s != %s {\n\t\t%s\n\t}\n", param.Field, zero, stmt)
+}
+
+// formatValue returns the expression converting the value
+// of a param into a string, the same way the decoder parses it
+func (g *generator) formatValue(t reflect.Type, expr string) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		g.use("strconv")
+		return "strconv.FormatInt(int64(" + expr + "), 10)"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		g.use("strconv")
+		return "strconv.FormatUint(uint64(" + expr + "), 10)"
+	}
+
+	if t.Name() == "string" && t.PkgPath() == "" {
+		return expr
+	}
+	return "string(" + expr + ")"
+}
+
+var cookieType = reflect.TypeOf(kapi.Cookie{})
+
+// writeResponse writes the code decoding the response, following the
+// same rules the adapters use for writing it, see kapi.WriteHandlerResponse
+func (g *generator) writeResponse(buf *bytes.Buffer, t reflect.Type) error {
+	if !isResponseStruct(t) {
+		fmt.Fprintf(buf, "\t_, respBody, err := c.do(req)\n\tif err != nil {\n\t\treturn response, err\n\t}\n")
+		return g.writeBodyDecoding(buf, t, "response")
+	}
+
+	// The fields are written first since the variables
+	// returned by c.do() are only declared if they are used:
+	var fields, cookies bytes.Buffer
+	usesResp, usesBody := false, false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		expr := "response." + field.Name
+
+		if _, ok := field.Tag.Lookup("status"); ok {
+			usesResp = true
+			fmt.Fprintf(&fields, "\t%s = %s\n", expr, g.convert(field.Type, "resp.StatusCode", "int"))
+		}
+
+		if key := strings.Split(field.Tag.Get("header"), ",")[0]; key != "" {
+			usesResp = true
+			err := g.writeResponseHeader(&fields, field.Type, expr, key)
+			if err != nil {
+				return err
+			}
+		}
+
+		if key := strings.Split(field.Tag.Get("cookie"), ",")[0]; key != "" {
+			usesResp = true
+			fmt.Fprintf(&cookies, "\t\tcase %q:\n", key)
+			switch field.Type {
+			case cookieType:
+				g.needsCookieHelper = true
+				fmt.Fprintf(&cookies, "\t\t\t%s = kapiCookie(cookie)\n", expr)
+			case reflect.PtrTo(cookieType):
+				g.needsCookieHelper = true
+				fmt.Fprintf(&cookies, "\t\t\tvalue := kapiCookie(cookie)\n\t\t\t%s = &value\n", expr)
+			default:
+				fmt.Fprintf(&cookies, "\t\t\t%s = %s\n", expr, g.convert(field.Type, "cookie.Value", "string"))
+			}
+		}
+
+		if field.Name == "Body" {
+			usesBody = true
+			err := g.writeBodyDecoding(&fields, field.Type, expr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	respVar, bodyVar := "_", "_"
+	if usesResp {
+		respVar = "resp"
+	}
+	if usesBody {
+		bodyVar = "respBody"
+	}
+	fmt.Fprintf(buf, "\t%s, %s, err := c.do(req)\n\tif err != nil {\n\t\treturn response, err\n\t}\n", respVar, bodyVar)
+	buf.Write(fields.Bytes())
+
+	if cookies.Len() > 0 {
+		fmt.Fprintf(buf, "\tfor _, cookie := range resp.Cookies() {\n\t\tswitch cookie.Name {\n")
+		buf.Write(cookies.Bytes())
+		fmt.Fprintf(buf, "\t\t}\n\t}\n")
+	}
+
+	return nil
+}
+
+func (g *generator) writeBodyDecoding(buf *bytes.Buffer, t reflect.Type, expr string) error {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		fmt.Fprintf(buf, "\t%s = %s\n", expr, g.convert(t, "respBody", "[]byte"))
+		return nil
+	}
+
+	if _, err := g.typeString(t); err != nil {
+		return err
+	}
+
+	g.use("encoding/json")
+	fmt.Fprintf(buf, "\tif len(respBody) > 0 {\n")
+	fmt.Fprintf(buf, "\t\terr = json.Unmarshal(respBody, &%s)\n", expr)
+	fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn response, err\n\t\t}\n\t}\n")
+	return nil
+}
+
+func (g *generator) writeResponseHeader(buf *bytes.Buffer, t reflect.Type, expr string, key string) error {
+	switch t.Kind() {
+	case reflect.String:
+		fmt.Fprintf(buf, "\t%s = %s\n", expr, g.convert(t, fmt.Sprintf("resp.Header.Get(%q)", key), "string"))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		g.use("strconv")
+		fmt.Fprintf(buf, "\tif v := resp.Header.Get(%q); v != \"\" {\n", key)
+		fmt.Fprintf(buf, "\t\tn, err := strconv.ParseInt(v, 10, %d)\n", t.Bits())
+		fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn response, err\n\t\t}\n")
+		fmt.Fprintf(buf, "\t\t%s = %s\n\t}\n", expr, g.convert(t, "n", "int64"))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		g.use("strconv")
+		fmt.Fprintf(buf, "\tif v := resp.Header.Get(%q); v != \"\" {\n", key)
+		fmt.Fprintf(buf, "\t\tn, err := strconv.ParseUint(v, 10, %d)\n", t.Bits())
+		fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn response, err\n\t\t}\n")
+		fmt.Fprintf(buf, "\t\t%s = %s\n\t}\n", expr, g.convert(t, "n", "uint64"))
+		return nil
+	}
+
+	return fmt.Errorf("response header fields must be strings or integers, but %s is of type %v", expr, t)
+}
+
+// isResponseStruct follows the rules used by the adapters for deciding
+// if the fields of the response are written separately
+func isResponseStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		_, hasStatus := field.Tag.Lookup("status")
+		hasHeader := strings.Split(field.Tag.Get("header"), ",")[0] != ""
+		hasCookie := strings.Split(field.Tag.Get("cookie"), ",")[0] != ""
+		_, hasContentType := field.Tag.Lookup("content-type")
+		if hasStatus || hasHeader || hasCookie || (field.Name == "Body" && hasContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// convert returns the expression converting the value of type
+// valueType into t, which must be a type the client can reference
+func (g *generator) convert(t reflect.Type, value string, valueType string) string {
+	typeName, err := g.typeString(t)
+	if err != nil || typeName == valueType {
+		return value
+	}
+	return typeName + "(" + value + ")"
+}
+
+// typeString returns the name of the type as it should be
+// written on the generated code, recording the imports it requires
+func (g *generator) typeString(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		switch {
+		case t.PkgPath() == "":
+			return t.Name(), nil
+		case strings.Contains(t.Name(), "["):
+			return "", fmt.Errorf("generic types such as %v are not supported", t)
+		case !token.IsExported(t.Name()):
+			return "", fmt.Errorf("the type %v is not exported", t)
+		case t.PkgPath() == g.opts.PackagePath:
+			return t.Name(), nil
+		}
+		return g.importName(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		elem, err := g.typeString(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		switch t.Kind() {
+		case reflect.Ptr:
+			return "*" + elem, nil
+		case reflect.Slice:
+			if elem == "uint8" {
+				// reflect can't tell byte and uint8 apart, but
+				// []byte is the name used by the Go community:
+				return "[]byte", nil
+			}
+			return "[]" + elem, nil
+		}
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), nil
+	case reflect.Map:
+		key, err := g.typeString(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeString(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "map[" + key + "]" + elem, nil
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+
+	return "", fmt.Errorf("the type %v must be a named type for being used on the client", t)
+}
+
+// use records an import of the standard library
+func (g *generator) use(importPath string) {
+	g.imports[importPath] = path.Base(importPath)
+}
+
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+var nonIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// importName returns the name used for referencing the package on the generated code
+func (g *generator) importName(importPath string) string {
+	if name, found := g.imports[importPath]; found {
+		return name
+	}
+
+	base := path.Base(importPath)
+	if versionSuffix.MatchString(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = nonIdentifierChars.ReplaceAllString(base, "_")
+
+	used := map[string]bool{}
+	for _, name := range g.imports {
+		used[name] = true
+	}
+
+	name := base
+	for i := 2; used[name] || reservedNames[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	g.imports[importPath] = name
+	return name
+}
+
+// initialisms are written in upper case on the names of the methods
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "ip": true,
+	"json": true, "uri": true, "url": true, "uuid": true,
+}
+
+// identifier converts the operation id, or the method and the path of the
+// route, into the name of a method, e.g. "GET /users/{id}" into "GetUsersByID"
+func identifier(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '/' }) {
+		prefix := ""
+		if strings.HasPrefix(part, "{") {
+			prefix = "By"
+		}
+
+		words := nonIdentifierChars.Split(part, -1)
+		if prefix != "" && strings.Trim(strings.Join(words, ""), "_") == "" {
+			// Greedy params such as {*} have no name:
+			words = []string{"Path"}
+		}
+
+		b.WriteString(prefix)
+		for _, word := range words {
+			for _, w := range strings.Split(word, "_") {
+				if w == "" {
+					continue
+				}
+				if initialisms[strings.ToLower(w)] {
+					b.WriteString(strings.ToUpper(w))
+					continue
+				}
+				b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+			}
+		}
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Call" + name
+	}
+	return name
+}
+
+func (g *generator) source() ([]byte, error) {
+	g.use("fmt")
+	g.use("io")
+	g.use("net/http")
+	g.use("strings")
+	if g.needsCookieHelper {
+		g.importName("github.com/vingarcia/kapi")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\nimport (\n", generatedHeader, g.opts.Package)
+
+	// The standard library imports come first just like goimports does:
+	var std, others []string
+	for importPath := range g.imports {
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			others = append(others, importPath)
+		} else {
+			std = append(std, importPath)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	for i, group := range [][]string{std, others} {
+		if i > 0 && len(others) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, importPath := range group {
+			if path.Base(importPath) == g.imports[importPath] {
+				fmt.Fprintf(&buf, "\t%q\n", importPath)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", g.imports[importPath], importPath)
+			}
+		}
+	}
+	fmt.Fprintf(&buf, ")\n")
+
+	buf.WriteString(clientCode)
+	if g.needsCookieHelper {
+		buf.WriteString(fmt.Sprintf(cookieHelperCode, g.imports["github.com/vingarcia/kapi"]))
+	}
+	buf.Write(g.body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error formatting the generated code: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+const clientCode = `
+// Client calls the routes of the API, its methods receive
+// the same args structs used by the handlers of the routes.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the API served on baseURL, e.g. "http://localhost:8080"
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is returned when the API responds with a status code outside of the 2xx range
+type Error struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, &Error{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+		}
+	}
+
+	return resp, body, nil
+}
+`
+
+const cookieHelperCode = `
+func kapiCookie(cookie *http.Cookie) %[1]s.Cookie {
+	sameSite := ""
+	switch cookie.SameSite {
+	case http.SameSiteLaxMode:
+		sameSite = "Lax"
+	case http.SameSiteStrictMode:
+		sameSite = "Strict"
+	case http.SameSiteNoneMode:
+		sameSite = "None"
+	}
+
+	return %[1]s.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		MaxAge:   cookie.MaxAge,
+		Expires:  cookie.Expires,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HttpOnly,
+		SameSite: sameSite,
+	}
+}
+`
@@ -0,0 +1,117 @@
+package clientgen_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/clientgen"
+	"github.com/vingarcia/kapi/clientgen/internal/fixtures/api"
+	"github.com/vingarcia/kapi/clientgen/internal/fixtures/apiclient"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("should match the client generated for the fixtures", func(t *testing.T) {
+		registry := kapi.NewRegistry()
+		api.Register(fiber.New(), registry)
+
+		src, err := clientgen.Generate(registry, clientgen.Options{Package: "apiclient"})
+		tt.AssertNoErr(t, err)
+
+		expected, err := os.ReadFile("internal/fixtures/apiclient/client.go")
+		tt.AssertNoErr(t, err)
+
+		// If this fails run `go generate ./...` and check the diff:
+		tt.AssertEqual(t, string(src), string(expected))
+	})
+
+	t.Run("should require the name of the package", func(t *testing.T) {
+		_, err := clientgen.Generate(kapi.NewRegistry(), clientgen.Options{})
+		tt.AssertErrContains(t, err, "the name of the package of the generated client is required")
+	})
+}
+
+// fiberTransport sends the requests of the client to the app in memory
+type fiberTransport struct {
+	app *fiber.App
+}
+
+func (f fiberTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f.app.Test(req, -1)
+}
+
+func TestGeneratedClient(t *testing.T) {
+	app := fiber.New()
+	api.Register(app, kapi.NewRegistry())
+
+	client := apiclient.NewClient("http://example.com/")
+	client.HTTPClient = &http.Client{Transport: fiberTransport{app}}
+
+	tests := []struct {
+		desc         string
+		args         api.GetUserArgs
+		expectedArgs api.GetUserArgs
+	}{
+		{
+			desc: "should send every param",
+			args: api.GetUserArgs{
+				ID:      42,
+				Token:   "Bearer fake",
+				Page:    3,
+				Size:    20,
+				Sort:    "age",
+				Session: "fake-session",
+				Filter:  api.Filter{Status: "active", Limit: 5},
+			},
+			expectedArgs: api.GetUserArgs{
+				ID:      42,
+				Token:   "Bearer fake",
+				Page:    3,
+				Size:    20,
+				Sort:    "age",
+				Session: "fake-session",
+				Filter:  api.Filter{Status: "active", Limit: 5},
+			},
+		},
+		{
+			desc: "should send the zero numbers of params with defaults",
+			args: api.GetUserArgs{
+				ID:    42,
+				Token: "Bearer fake",
+			},
+			expectedArgs: api.GetUserArgs{
+				ID:    42,
+				Token: "Bearer fake",
+				// Empty strings are omitted, so the default is used instead:
+				Sort: "name",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			received, err := client.GetUser(context.Background(), test.args)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, received.Args, test.expectedArgs)
+		})
+	}
+
+	t.Run("should decode structs with a Body field as the response body", func(t *testing.T) {
+		comment, err := client.GetComment(context.Background(), api.GetCommentArgs{ID: 42})
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, comment, api.Comment{ID: 42, Body: "fake comment"})
+	})
+
+	t.Run("should return the errors of the API", func(t *testing.T) {
+		_, err := client.GetUser(context.Background(), api.GetUserArgs{ID: 42})
+
+		var apiErr *apiclient.Error
+		tt.AssertEqual(t, errors.As(err, &apiErr), true)
+		tt.AssertEqual(t, apiErr.StatusCode, http.StatusBadRequest)
+		tt.AssertEqual(t, string(apiErr.Body), "required header param 'Authorization' is empty")
+	})
+}
@@ -0,0 +1,64 @@
+// Package api contains the routes used for checking that the
+// clients generated by clientgen send the args structs unchanged.
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	adapter "github.com/vingarcia/kapi/adapters/fiberV2"
+)
+
+//go:generate go run ../apiclient/gen_client.go
+
+type GetUserArgs struct {
+	ID      uint64 `path:"id"`
+	Token   string `header:"Authorization"`
+	Page    int    `query:"page" default:"1"`
+	Size    uint8  `query:"size"`
+	Sort    string `query:"sort" default:"name"`
+	Session string `cookie:"session,optional"`
+	Filter  Filter `query:"filter,deepObject"`
+}
+
+type Filter struct {
+	Status string `query:"status"`
+	Limit  int    `query:"limit" default:"10"`
+}
+
+// Received contains the args decoded by the handler
+type Received struct {
+	Args GetUserArgs `json:"args"`
+}
+
+type GetCommentArgs struct {
+	ID uint64 `path:"id"`
+}
+
+// Comment has a field named Body which is not the response body,
+// since it is not tagged with `content-type`
+type Comment struct {
+	ID   uint64 `json:"id"`
+	Body string `json:"body"`
+}
+
+// Register adds the routes of the API to the app and the registry
+func Register(app fiber.Router, registry *kapi.Registry) {
+	adapter.Get(app, "/users/:id", GetUser,
+		kapi.WithRegistry(registry),
+		kapi.WithOperationID("GetUser"),
+	)
+	adapter.Get(app, "/comments/:id", GetComment,
+		kapi.WithRegistry(registry),
+		kapi.WithOperationID("GetComment"),
+	)
+}
+
+func GetUser(ctx context.Context, args GetUserArgs) (Received, error) {
+	return Received{Args: args}, nil
+}
+
+func GetComment(ctx context.Context, args GetCommentArgs) (Comment, error) {
+	return Comment{ID: args.ID, Body: "fake comment"}, nil
+}
@@ -0,0 +1,138 @@
+// Code generated by kapi clientgen. DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/vingarcia/kapi/clientgen/internal/fixtures/api"
+)
+
+// Client calls the routes of the API, its methods receive
+// the same args structs used by the handlers of the routes.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the API served on baseURL, e.g. "http://localhost:8080"
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is returned when the API responds with a status code outside of the 2xx range
+type Error struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, &Error{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+		}
+	}
+
+	return resp, body, nil
+}
+
+// GetUser calls GET /users/{id}
+func (c *Client) GetUser(ctx context.Context, args api.GetUserArgs) (api.Received, error) {
+	var response api.Received
+
+	query := url.Values{}
+	query.Set("page", strconv.FormatInt(int64(args.Page), 10))
+	if args.Size != 0 {
+		query.Set("size", strconv.FormatUint(uint64(args.Size), 10))
+	}
+	if args.Sort != "" {
+		query.Set("sort", args.Sort)
+	}
+	if args.Filter.Status != "" {
+		query.Set("filter[status]", args.Filter.Status)
+	}
+	query.Set("filter[limit]", strconv.FormatInt(int64(args.Filter.Limit), 10))
+
+	endpoint := c.BaseURL + "/users/" + url.PathEscape(strconv.FormatUint(uint64(args.ID), 10))
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return response, err
+	}
+	req.Header.Set("Authorization", args.Token)
+	if args.Session != "" {
+		req.AddCookie(&http.Cookie{Name: "session", Value: args.Session})
+	}
+
+	_, respBody, err := c.do(req)
+	if err != nil {
+		return response, err
+	}
+	if len(respBody) > 0 {
+		err = json.Unmarshal(respBody, &response)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// GetComment calls GET /comments/{id}
+func (c *Client) GetComment(ctx context.Context, args api.GetCommentArgs) (api.Comment, error) {
+	var response api.Comment
+
+	endpoint := c.BaseURL + "/comments/" + url.PathEscape(strconv.FormatUint(uint64(args.ID), 10))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return response, err
+	}
+
+	_, respBody, err := c.do(req)
+	if err != nil {
+		return response, err
+	}
+	if len(respBody) > 0 {
+		err = json.Unmarshal(respBody, &response)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,28 @@
+//go:build ignore
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/clientgen"
+	"github.com/vingarcia/kapi/clientgen/internal/fixtures/api"
+)
+
+func main() {
+	registry := kapi.NewRegistry()
+	api.Register(fiber.New(), registry)
+
+	src, err := clientgen.Generate(registry, clientgen.Options{Package: "apiclient"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile("../apiclient/client.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
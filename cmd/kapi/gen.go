@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const generatedHeader = "// Code generated by kapi gen. DO NOT EDIT."
+
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	typeNames := flags.String("type", "", "comma separated list of the args structs, all the tagged structs are used by default")
+	output := flags.String("output", "kapi_decoders.go", "name of the generated file")
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	src, err := generate(dir, *typeNames)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, *output), src, 0644)
+}
+
+// generate returns the source of the decoders for the comma separated
+// list of structs on typeNames, or for all the tagged structs if it is empty.
+func generate(dir string, typeNames string) ([]byte, error) {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if typeNames != "" {
+		names = strings.Split(typeNames, ",")
+	} else {
+		names = taggedStructs(pkg)
+	}
+
+	g := newGenerator(pkg)
+	for _, name := range names {
+		err := g.addStruct(name)
+		if err != nil {
+			if typeNames != "" {
+				return nil, err
+			}
+
+			// When the types are not informed explicitly
+			// the unsupported structs are just skipped:
+			fmt.Fprintf(os.Stderr, "kapi gen: skipping %s: %s\n", name, err.Error())
+		}
+	}
+
+	return g.source()
+}
+
+// loadPackage parses and type checks the package on dir,
+// ignoring the test files and the files generated by kapi.
+func loadPackage(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			if isGeneratedByKapi(file) {
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files found on '%s'", dir)
+	}
+
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// Errors are ignored so the decoders can be generated even if the package
+		// depends on other generated code, the structs are checked later anyway:
+		Error: func(error) {},
+	}
+	pkg, _ := config.Check(files[0].Name.Name, fset, files, nil)
+	return pkg, nil
+}
+
+func isGeneratedByKapi(file *ast.File) bool {
+	for _, comment := range file.Comments {
+		if comment.Pos() > file.Package {
+			break
+		}
+		if strings.Contains(comment.Text(), strings.TrimPrefix(generatedHeader, "// ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// taggedStructs lists the structs of the package with fields
+// tagged with at least one of the sources supported by kapi.
+func taggedStructs(pkg *types.Package) (names []string) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+
+		st, ok := typeName.Type().Underlying().(*types.Struct)
+		if ok && hasSourceTags(st) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var sources = []string{"path", "header", "query", "cookie", "context", "request"}
+
+func hasSourceTags(st *types.Struct) bool {
+	for i := 0; i < st.NumFields(); i++ {
+		tag := reflect.StructTag(st.Tag(i))
+		for _, source := range sources {
+			if _, ok := tag.Lookup(source); ok {
+				return true
+			}
+		}
+
+		_, inline := tag.Lookup("kapi")
+		if nested, ok := st.Field(i).Type().Underlying().(*types.Struct); ok && (st.Field(i).Embedded() || inline) {
+			if hasSourceTags(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string
+	body    bytes.Buffer
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	return &generator{
+		pkg: pkg,
+		imports: map[string]string{
+			"github.com/vingarcia/kapi": "kapi",
+		},
+	}
+}
+
+// field describes how to fill one of the fields of the args struct
+type field struct {
+	source   string
+	key      string
+	name     string
+	expr     string
+	typ      types.Type
+	required bool
+	def      string
+}
+
+func (g *generator) addStruct(name string) error {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("type %s not found on package %s", name, g.pkg.Name())
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return fmt.Errorf("%s is not a named type", name)
+	}
+	if named.TypeParams().Len() > 0 {
+		return fmt.Errorf("generic structs are not supported")
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s is not a struct", name)
+	}
+
+	var fields []field
+	err := collectFields(st, "args.", "", &fields)
+	if err != nil {
+		return err
+	}
+
+	// The fields are decoded in the same order used by the reflective
+	// decoder, i.e. sorted by key within each source, so when more than
+	// one param is invalid both report the same error:
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].key < fields[j].key
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// DecodeRequest implements the kapi.RequestDecoder interface\n")
+	fmt.Fprintf(&buf, "func (args *%s) DecodeRequest(request kapi.RequestAdapter, funcInfo kapi.DecodedHandlerFunction) error {\n", name)
+
+	err = g.writeBody(&buf, st)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		for _, f := range fields {
+			if f.source != source {
+				continue
+			}
+			err := g.writeField(&buf, f)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(&buf, "\treturn nil\n}\n")
+
+	fmt.Fprintf(&buf, "\n// KapiFingerprint implements the kapi.RequestDecoder interface\n")
+	fmt.Fprintf(&buf, "func (args *%s) KapiFingerprint() string {\n\treturn %q\n}\n", name, name+":"+fingerprint(st))
+
+	g.body.Write(buf.Bytes())
+	return nil
+}
+
+// collectFields follows the same rules the reflective decoder uses,
+// so embedded structs and the fields tagged with `kapi:"inline"`
+// are treated as groups of params.
+func collectFields(st *types.Struct, exprPrefix string, namePrefix string, fields *[]field) error {
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		name := namePrefix + v.Name()
+		expr := exprPrefix + v.Name()
+
+		hasSource := false
+		for _, source := range sources {
+			value, ok := tag.Lookup(source)
+			if !ok {
+				continue
+			}
+			hasSource = true
+
+			f, err := parseField(source, value, tag, name, expr, v.Type())
+			if err != nil {
+				return err
+			}
+			*fields = append(*fields, f)
+		}
+
+		_, inline := tag.Lookup("kapi")
+		if hasSource || !(v.Embedded() || inline) {
+			continue
+		}
+
+		if nested, ok := v.Type().Underlying().(*types.Struct); ok {
+			err := collectFields(nested, expr+".", name+".", fields)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseField(source string, value string, tag reflect.StructTag, name string, expr string, typ types.Type) (field, error) {
+	opts := strings.Split(value, ",")
+	f := field{
+		source:   source,
+		key:      opts[0],
+		name:     name,
+		expr:     expr,
+		typ:      typ,
+		required: source == "path" || source == "header" || source == "cookie" || source == "context",
+		def:      tag.Get("default"),
+	}
+
+	if strings.HasSuffix(f.key, "*") {
+		return field{}, fmt.Errorf("field %s: catch-all params are not supported by the generated decoders", name)
+	}
+
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "required":
+			f.required = true
+		case "optional":
+			f.required = false
+		case "deepObject":
+			return field{}, fmt.Errorf("field %s: deepObject params are not supported by the generated decoders", name)
+		}
+	}
+
+	if source == "path" || source == "header" || source == "query" || source == "cookie" {
+		if _, ok := parseFuncs[basicKind(typ)]; !ok && basicKind(typ) != types.String {
+			return field{}, fmt.Errorf("field %s has type %v which is not supported for %s params", name, typ, source)
+		}
+	}
+	if source == "request" && basicKind(typ) != types.String {
+		return field{}, fmt.Errorf("field %s must be a string to be used with the request tag", name)
+	}
+
+	return f, nil
+}
+
+func basicKind(t types.Type) types.BasicKind {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return types.Invalid
+	}
+	return basic.Kind()
+}
+
+// parseFuncs contains the same strconv calls used by the reflective decoder,
+// so both produce the same errors, and the matching reflect.Kind.
+var parseFuncs = map[types.BasicKind]struct {
+	call       string
+	resultType string
+	kind       string
+}{
+	types.Int:    {"strconv.Atoi(param)", "int", "reflect.Int"},
+	types.Int8:   {"strconv.ParseInt(param, 10, 8)", "int64", "reflect.Int8"},
+	types.Int16:  {"strconv.ParseInt(param, 10, 16)", "int64", "reflect.Int16"},
+	types.Int32:  {"strconv.ParseInt(param, 10, 32)", "int64", "reflect.Int32"},
+	types.Int64:  {"strconv.ParseInt(param, 10, 64)", "int64", "reflect.Int64"},
+	types.Uint:   {"strconv.ParseUint(param, 10, 0)", "uint64", "reflect.Uint"},
+	types.Uint8:  {"strconv.ParseUint(param, 10, 8)", "uint64", "reflect.Uint8"},
+	types.Uint16: {"strconv.ParseUint(param, 10, 16)", "uint64", "reflect.Uint16"},
+	types.Uint32: {"strconv.ParseUint(param, 10, 32)", "uint64", "reflect.Uint32"},
+	types.Uint64: {"strconv.ParseUint(param, 10, 64)", "uint64", "reflect.Uint64"},
+}
+
+var getters = map[string]string{
+	"path":   "GetPathParam",
+	"header": "GetHeaderParam",
+	"query":  "GetQueryParam",
+	"cookie": "GetCookie",
+}
+
+func (g *generator) writeBody(buf *bytes.Buffer, st *types.Struct) error {
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if v.Name() != "Body" {
+			continue
+		}
+
+		if slice, ok := v.Type().(*types.Slice); ok && basicKind(slice.Elem()) == types.Byte {
+			fmt.Fprintf(buf, "\targs.Body = request.GetBody()\n\n")
+			return nil
+		}
+
+		contentType := strings.Split(reflect.StructTag(st.Tag(i)).Get("content-type"), ",")[0]
+		switch contentType {
+		case "", "application/json":
+			fmt.Fprintf(buf, "\tif err := funcInfo.DecodeJSONBody(request, %q, &args.Body); err != nil {\n\t\treturn err\n\t}\n\n", v.Name())
+		default:
+			return fmt.Errorf("mimetype '%s' is not supported yet for field %s", contentType, v.Name())
+		}
+		return nil
+	}
+	return nil
+}
+
+func (g *generator) writeField(buf *bytes.Buffer, f field) error {
+	switch f.source {
+	case "context":
+		fmt.Fprintf(buf, "\tif err := funcInfo.DecodeContextValue(request, %q, &%s); err != nil {\n\t\treturn err\n\t}\n", f.key, f.expr)
+		return nil
+	case "request":
+		fmt.Fprintf(buf, "\t%s = %s\n", f.expr, g.convert(f.typ, fmt.Sprintf("funcInfo.RequestInfo(request, %q)", f.key), "string"))
+		return nil
+	}
+
+	fmt.Fprintf(buf, "\t{\n\t\tparam := request.%s(%q)\n", getters[f.source], f.key)
+	indent := "\t\t"
+	closeIf := false
+	switch {
+	case f.def != "":
+		fmt.Fprintf(buf, "\t\tif param == \"\" {\n\t\t\tparam = %q\n\t\t}\n", f.def)
+	case f.required:
+		fmt.Fprintf(buf, "\t\tif param == \"\" {\n\t\t\treturn funcInfo.MissingValue(request, %q, %q, %q)\n\t\t}\n", f.source, f.key, f.name)
+	default:
+		fmt.Fprintf(buf, "\t\tif param != \"\" {\n")
+		indent = "\t\t\t"
+		closeIf = true
+	}
+
+	if basicKind(f.typ) == types.String {
+		fmt.Fprintf(buf, "%s%s = %s\n", indent, f.expr, g.convert(f.typ, "param", "string"))
+	} else {
+		g.imports["strconv"] = "strconv"
+		g.imports["reflect"] = "reflect"
+		parse := parseFuncs[basicKind(f.typ)]
+		fmt.Fprintf(buf, "%sv, err := %s\n", indent, parse.call)
+		fmt.Fprintf(buf, "%sif err != nil {\n", indent)
+		fmt.Fprintf(buf, "%s\treturn funcInfo.InvalidValue(request, %q, %q, %q, %s, err)\n", indent, f.source, f.key, f.name, parse.kind)
+		fmt.Fprintf(buf, "%s}\n", indent)
+		fmt.Fprintf(buf, "%s%s = %s\n", indent, f.expr, g.convert(f.typ, "v", parse.resultType))
+	}
+
+	if closeIf {
+		fmt.Fprintf(buf, "\t\t}\n")
+	}
+	fmt.Fprintf(buf, "\t}\n")
+	return nil
+}
+
+// convert returns the expression converting the value of type valueType
+// into the type of the field, recording the imports it requires.
+func (g *generator) convert(t types.Type, value string, valueType string) string {
+	typeName := types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+		g.imports[pkg.Path()] = pkg.Name()
+		return pkg.Name()
+	})
+
+	if typeName == valueType {
+		return value
+	}
+	return typeName + "(" + value + ")"
+}
+
+func (g *generator) source() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\nimport (\n", generatedHeader, g.pkg.Name())
+
+	// The standard library imports come first just like goimports does:
+	var std, others []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	for i, group := range [][]string{std, others} {
+		if i > 0 && len(std) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, path := range group {
+			if filepath.Base(path) == g.imports[path] {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", g.imports[path], path)
+			}
+		}
+	}
+	fmt.Fprintf(&buf, ")\n")
+	buf.Write(g.body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error formatting the generated code: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+// fingerprint must produce the same value as kapi.StructFingerprint,
+// the name of the type is added as a prefix by addStruct.
+func fingerprint(st *types.Struct) string {
+	var b strings.Builder
+	writeFingerprint(&b, st, "")
+
+	h := fnv.New64a()
+	h.Write([]byte(b.String()))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func writeFingerprint(b *strings.Builder, st *types.Struct, namePrefix string) {
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		name := namePrefix + v.Name()
+		fmt.Fprintf(b, "%s %q\n", name, st.Tag(i))
+
+		_, inline := reflect.StructTag(st.Tag(i)).Lookup("kapi")
+		if nested, ok := v.Type().Underlying().(*types.Struct); ok && (v.Embedded() || inline) {
+			writeFingerprint(b, nested, name+".")
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/cmd/kapi/internal/fixtures"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("should match the decoders generated for the fixtures", func(t *testing.T) {
+		src, err := generate("internal/fixtures", "HeadersArgs,UserArgs,BodyArgs")
+		tt.AssertNoErr(t, err)
+
+		expected, err := os.ReadFile("internal/fixtures/kapi_decoders.go")
+		tt.AssertNoErr(t, err)
+
+		// If this fails run `go generate ./...` and check the diff:
+		tt.AssertEqual(t, string(src), string(expected))
+	})
+
+	t.Run("should report unsupported structs informed explicitly", func(t *testing.T) {
+		_, err := generate("internal/fixtures", "NotFound")
+		tt.AssertErrContains(t, err, "type NotFound not found on package fixtures")
+	})
+}
+
+// The reflective types have the same fields as the fixtures
+// but not their methods, so they are decoded with reflection:
+type (
+	reflectiveHeadersArgs fixtures.HeadersArgs
+	reflectiveUserArgs    fixtures.UserArgs
+	reflectiveBodyArgs    fixtures.BodyArgs
+)
+
+func TestGeneratedDecodersParity(t *testing.T) {
+	fixtureTypes := []struct {
+		generated  interface{}
+		reflective interface{}
+	}{
+		{fixtures.HeadersArgs{}, reflectiveHeadersArgs{}},
+		{fixtures.UserArgs{}, reflectiveUserArgs{}},
+		{fixtures.BodyArgs{}, reflectiveBodyArgs{}},
+	}
+
+	validRequest := func() *kapitest.RequestBuilder {
+		return kapitest.Request().
+			Method("POST").
+			Path("org", "7").
+			Path("id", "42").
+			Header("Zeta", "z").
+			Header("Alpha", "a").
+			Header("Authorization", "Bearer fake").
+			Query("verbose", "true").
+			Query("size", "20").
+			Query("cursor", "fake-cursor").
+			Cookie("session", "fake-session").
+			Context("user", "fake-user").
+			JSON(map[string]string{"name": "fake-name"})
+	}
+
+	tests := []struct {
+		desc    string
+		request *kapitest.RequestBuilder
+	}{
+		{
+			desc:    "should decode empty requests",
+			request: kapitest.Request(),
+		},
+		{
+			desc:    "should decode valid requests",
+			request: validRequest(),
+		},
+		{
+			desc: "should decode requests with several invalid params",
+			request: validRequest().
+				Path("org", "not-a-number").
+				Path("id", "-1").
+				Query("offset", "99999999999").
+				Query("size", "300"),
+		},
+		{
+			desc: "should decode requests with several missing params",
+			request: kapitest.Request().
+				Path("org", "7").
+				Query("limit", "not-a-number"),
+		},
+		{
+			desc:    "should decode requests with invalid bodies",
+			request: validRequest().Body([]byte("{")),
+		},
+		{
+			desc: "should decode requests with context values of other types",
+			request: validRequest().
+				Context("user", 42),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			for _, types := range fixtureTypes {
+				generatedValue, generatedErr := decodeArgs(t, test.request, types.generated, true)
+				reflectiveValue, reflectiveErr := decodeArgs(t, test.request, types.reflective, false)
+
+				tt.AssertEqual(t, errorMessage(generatedErr), errorMessage(reflectiveErr))
+				if generatedErr == nil {
+					tt.AssertEqual(t, generatedValue.Interface(), reflectiveValue.Convert(generatedValue.Type()).Interface())
+				}
+			}
+		})
+	}
+}
+
+var contextType = reflect.TypeOf(new(context.Context)).Elem()
+
+// decodeArgs decodes the request into a value of the same type as args,
+// checking that it is decoded with a generated decoder only if expected.
+func decodeArgs(t *testing.T, b *kapitest.RequestBuilder, args interface{}, expectGenerated bool) (reflect.Value, error) {
+	argsType := reflect.TypeOf(args)
+	_, isGenerated := reflect.New(argsType).Interface().(kapi.RequestDecoder)
+	tt.AssertEqual(t, isGenerated, expectGenerated)
+
+	fnType := reflect.FuncOf(
+		[]reflect.Type{contextType, argsType},
+		[]reflect.Type{reflect.TypeOf(new(error)).Elem()},
+		false,
+	)
+	fnInfo, err := kapi.TryDecodeHandlerFunction(fnType, []reflect.Type{contextType})
+	tt.AssertNoErr(t, err)
+
+	var value reflect.Value
+	var decodeErr error
+	_, err = b.Serve(func(request kapi.RequestAdapter) error {
+		value, decodeErr = kapi.UnmarshalRequestAsStruct(request, fnInfo)
+		return nil
+	})
+	tt.AssertNoErr(t, err)
+
+	if decodeErr != nil {
+		return reflect.Value{}, decodeErr
+	}
+	return value.Elem(), nil
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,37 @@
+// Package fixtures contains the args structs used for checking that
+// the decoders generated by `kapi gen` behave like the reflective ones.
+package fixtures
+
+//go:generate go run github.com/vingarcia/kapi/cmd/kapi gen -type HeadersArgs,UserArgs,BodyArgs
+
+// HeadersArgs has fields declared out of the order they are decoded
+type HeadersArgs struct {
+	Zeta  string `header:"Zeta"`
+	Alpha string `header:"Alpha"`
+}
+
+type UserArgs struct {
+	Org     int    `path:"org"`
+	ID      uint64 `path:"id"`
+	Token   string `header:"Authorization"`
+	Offset  int32  `query:"offset"`
+	Limit   int    `query:"limit" default:"10"`
+	Verbose string `query:"verbose,required"`
+	Session string `cookie:"session,optional"`
+	User    string `context:"user"`
+	Method  string `request:"method"`
+
+	Page pagination `kapi:"inline"`
+}
+
+type pagination struct {
+	Size   uint8  `query:"size"`
+	Cursor string `query:"cursor"`
+}
+
+type BodyArgs struct {
+	ID   int `path:"id"`
+	Body struct {
+		Name string `json:"name"`
+	}
+}
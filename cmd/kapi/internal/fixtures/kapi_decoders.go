@@ -0,0 +1,152 @@
+// Code generated by kapi gen. DO NOT EDIT.
+
+package fixtures
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/vingarcia/kapi"
+)
+
+// DecodeRequest implements the kapi.RequestDecoder interface
+func (args *HeadersArgs) DecodeRequest(request kapi.RequestAdapter, funcInfo kapi.DecodedHandlerFunction) error {
+	{
+		param := request.GetHeaderParam("Alpha")
+		if param == "" {
+			return funcInfo.MissingValue(request, "header", "Alpha", "Alpha")
+		}
+		args.Alpha = param
+	}
+	{
+		param := request.GetHeaderParam("Zeta")
+		if param == "" {
+			return funcInfo.MissingValue(request, "header", "Zeta", "Zeta")
+		}
+		args.Zeta = param
+	}
+	return nil
+}
+
+// KapiFingerprint implements the kapi.RequestDecoder interface
+func (args *HeadersArgs) KapiFingerprint() string {
+	return "HeadersArgs:930cb80a5c9d1bed"
+}
+
+// DecodeRequest implements the kapi.RequestDecoder interface
+func (args *UserArgs) DecodeRequest(request kapi.RequestAdapter, funcInfo kapi.DecodedHandlerFunction) error {
+	{
+		param := request.GetPathParam("id")
+		if param == "" {
+			return funcInfo.MissingValue(request, "path", "id", "ID")
+		}
+		v, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return funcInfo.InvalidValue(request, "path", "id", "ID", reflect.Uint64, err)
+		}
+		args.ID = v
+	}
+	{
+		param := request.GetPathParam("org")
+		if param == "" {
+			return funcInfo.MissingValue(request, "path", "org", "Org")
+		}
+		v, err := strconv.Atoi(param)
+		if err != nil {
+			return funcInfo.InvalidValue(request, "path", "org", "Org", reflect.Int, err)
+		}
+		args.Org = v
+	}
+	{
+		param := request.GetHeaderParam("Authorization")
+		if param == "" {
+			return funcInfo.MissingValue(request, "header", "Authorization", "Token")
+		}
+		args.Token = param
+	}
+	{
+		param := request.GetQueryParam("cursor")
+		if param != "" {
+			args.Page.Cursor = param
+		}
+	}
+	{
+		param := request.GetQueryParam("limit")
+		if param == "" {
+			param = "10"
+		}
+		v, err := strconv.Atoi(param)
+		if err != nil {
+			return funcInfo.InvalidValue(request, "query", "limit", "Limit", reflect.Int, err)
+		}
+		args.Limit = v
+	}
+	{
+		param := request.GetQueryParam("offset")
+		if param != "" {
+			v, err := strconv.ParseInt(param, 10, 32)
+			if err != nil {
+				return funcInfo.InvalidValue(request, "query", "offset", "Offset", reflect.Int32, err)
+			}
+			args.Offset = int32(v)
+		}
+	}
+	{
+		param := request.GetQueryParam("size")
+		if param != "" {
+			v, err := strconv.ParseUint(param, 10, 8)
+			if err != nil {
+				return funcInfo.InvalidValue(request, "query", "size", "Page.Size", reflect.Uint8, err)
+			}
+			args.Page.Size = uint8(v)
+		}
+	}
+	{
+		param := request.GetQueryParam("verbose")
+		if param == "" {
+			return funcInfo.MissingValue(request, "query", "verbose", "Verbose")
+		}
+		args.Verbose = param
+	}
+	{
+		param := request.GetCookie("session")
+		if param != "" {
+			args.Session = param
+		}
+	}
+	if err := funcInfo.DecodeContextValue(request, "user", &args.User); err != nil {
+		return err
+	}
+	args.Method = funcInfo.RequestInfo(request, "method")
+	return nil
+}
+
+// KapiFingerprint implements the kapi.RequestDecoder interface
+func (args *UserArgs) KapiFingerprint() string {
+	return "UserArgs:47ffe3bd9ecf5936"
+}
+
+// DecodeRequest implements the kapi.RequestDecoder interface
+func (args *BodyArgs) DecodeRequest(request kapi.RequestAdapter, funcInfo kapi.DecodedHandlerFunction) error {
+	if err := funcInfo.DecodeJSONBody(request, "Body", &args.Body); err != nil {
+		return err
+	}
+
+	{
+		param := request.GetPathParam("id")
+		if param == "" {
+			return funcInfo.MissingValue(request, "path", "id", "ID")
+		}
+		v, err := strconv.Atoi(param)
+		if err != nil {
+			return funcInfo.InvalidValue(request, "path", "id", "ID", reflect.Int, err)
+		}
+		args.ID = v
+	}
+	return nil
+}
+
+// KapiFingerprint implements the kapi.RequestDecoder interface
+func (args *BodyArgs) KapiFingerprint() string {
+	return "BodyArgs:1e41302aaf360b86"
+}
@@ -0,0 +1,41 @@
+// Command kapi contains the code generators of the kapi library.
+//
+// Usage:
+//
+//	kapi gen [-type T1,T2] [-output file] [dir]
+//
+// The `gen` command generates decoders for the args structs of the package
+// in dir (the current directory by default), which are then used by `Adapt`
+// instead of reflection. It is usually invoked with `go generate`:
+//
+//	//go:generate go run github.com/vingarcia/kapi/cmd/kapi gen -type GetUserArgs
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "gen":
+		err = runGen(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kapi %s: %s\n", os.Args[1], err.Error())
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: kapi gen [-type T1,T2] [-output file] [dir]")
+	os.Exit(2)
+}
@@ -1,6 +1,6 @@
 package kapi
 
-type any = interface{}
+import "context"
 
 // RequestAdapter is the minimum interface required for interacting
 // with an input request and return a response.
@@ -15,16 +15,62 @@ type RequestAdapter interface {
 	GetBody() []byte
 
 	// All the following params should return the appropriate value for the
-	// param named `paramName` on the path, header or query
+	// param named `paramName` on the path, header, query or cookies
 	//
 	// If no value is found it should return an empty string
 	GetPathParam(paramName string) string
 	GetHeaderParam(paramName string) string
 	GetQueryParam(paramName string) string
+	GetCookie(cookieName string) string
+
+	// These methods should call the visitor once for each header or query
+	// param of the request, they are used for filling catch-all fields, e.g.:
+	//
+	//	Metadata map[string]string `header:"X-Meta-*"`
+	//
+	// Params with multiple values should be visited once per value.
+	VisitHeaders(visitor func(key string, value string))
+	VisitQueryParams(visitor func(key string, value string))
+
+	// The following methods return metadata about the request,
+	// they are used for filling the fields tagged with `request:"..."`
+	GetMethod() string
+	GetPath() string
+	GetHost() string
+	// GetScheme should return either "http" or "https"
+	GetScheme() string
+	// GetRemoteIP should return the IP of the direct peer of the connection
+	// without considering headers like `X-Forwarded-For`
+	GetRemoteIP() string
+	GetRawQuery() string
+	GetRequestURI() string
+
+	// GetContext returns the context.Context passed to handlers of type:
+	//
+	//	func(ctx context.Context, args MyArgs) error
+	//
+	// It should carry the deadlines, the cancellation signal and
+	// the context values of the request.
+	GetContext() context.Context
 
 	// This function should return the value as an emtpy
 	// interface as it will be converted to the type
 	// described on the adapter's input struct.
-	GetContextValue(contextKey string) any
-	SetContextValue(contextKey string, value any)
+	//
+	// String keys should be stored on the native storage of the
+	// framework, e.g. `ctx.Locals()` on fiber, so values set by other
+	// middlewares are visible to kapi and vice versa. Other keys,
+	// e.g. `type userKey struct{}`, should be stored on the context
+	// returned by GetContext.
+	GetContextValue(contextKey any) any
+	SetContextValue(contextKey any, value any)
+
+	// The following methods are used for writing the response
+	// by `kapi.WriteJSON` and for handlers that return a value, e.g.:
+	//
+	//	func(ctx *fiber.Ctx, args MyArgs) (MyResponse, error)
+	SetStatus(statusCode int)
+	SetHeader(key string, value string)
+	SetCookie(cookie Cookie)
+	WriteBody(body []byte) error
 }
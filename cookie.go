@@ -0,0 +1,28 @@
+package kapi
+
+import "time"
+
+// Cookie describes a cookie that should be set on the response.
+//
+// It can be used as the type of the response struct fields
+// tagged with `cookie:"name"`, e.g.:
+//
+//	type CreateSessionResponse struct {
+//	  Session kapi.Cookie `cookie:"sid"`
+//	}
+//
+// The name used on the tag takes precedence over the Name attribute.
+type Cookie struct {
+	Name     string
+	Value    string
+	Path     string
+	Domain   string
+	MaxAge   int
+	Expires  time.Time
+	Secure   bool
+	HTTPOnly bool
+
+	// SameSite should be one of "Lax", "Strict", "None" or empty
+	// for leaving the decision to the framework.
+	SameSite string
+}
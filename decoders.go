@@ -0,0 +1,181 @@
+package kapi
+
+import (
+	"encoding/json"
+	"net/textproto"
+	"reflect"
+	"strconv"
+)
+
+// fieldDecoder reads one of the values of the request and stores it
+// on the corresponding field of the args struct, the decoders are
+// compiled once by TryDecodeHandlerFunction so no maps are iterated
+// and no information about the fields is looked up per request.
+type fieldDecoder func(request RequestAdapter, args reflect.Value) error
+
+// compileDecoders builds the decoders of every field of the args struct,
+// in the same order the values were decoded before: body, path, header,
+// query, cookie, context, catch-all, deepObject and request params.
+func compileDecoders(
+	cfg config,
+	bodyContentType string,
+	bodyInfo *tagInfo,
+	params map[string]map[string]tagInfo,
+	headerCatchAll []tagInfo,
+	queryCatchAll []tagInfo,
+	queryDeepObjects []tagInfo,
+) (decoders []fieldDecoder) {
+	handleError := cfg.errorHandler
+	trustedProxies := cfg.trustedProxies
+
+	if bodyInfo != nil {
+		decoders = append(decoders, compileBodyDecoder(handleError, bodyContentType, *bodyInfo))
+	}
+
+	paramSources := []struct {
+		name string
+		get  func(request RequestAdapter, key string) string
+	}{
+		{"path", RequestAdapter.GetPathParam},
+		{"header", RequestAdapter.GetHeaderParam},
+		{"query", RequestAdapter.GetQueryParam},
+		{"cookie", RequestAdapter.GetCookie},
+	}
+	for _, source := range paramSources {
+		for _, key := range sortedKeys(params[source.name]) {
+			decoders = append(decoders, compileParamDecoder(handleError, source.name, key, params[source.name][key], source.get))
+		}
+	}
+
+	for _, key := range sortedKeys(params["context"]) {
+		key, info := key, params["context"][key]
+		decoders = append(decoders, func(request RequestAdapter, args reflect.Value) error {
+			return decodeContextValue(request, handleError, key, info, args.FieldByIndex(info.Index))
+		})
+	}
+
+	for _, info := range headerCatchAll {
+		info := info
+		decoders = append(decoders, func(request RequestAdapter, args reflect.Value) error {
+			args.FieldByIndex(info.Index).Set(
+				buildCatchAllMap(info, request.VisitHeaders, textproto.CanonicalMIMEHeaderKey),
+			)
+			return nil
+		})
+	}
+	for _, info := range queryCatchAll {
+		info := info
+		decoders = append(decoders, func(request RequestAdapter, args reflect.Value) error {
+			args.FieldByIndex(info.Index).Set(
+				buildCatchAllMap(info, request.VisitQueryParams, nil),
+			)
+			return nil
+		})
+	}
+
+	for _, info := range queryDeepObjects {
+		info := info
+		decoders = append(decoders, func(request RequestAdapter, args reflect.Value) error {
+			node := parseDeepObject(request, info.Key)
+			if node == nil && info.Required {
+				return handleError(request, newMissingValueError("query", info.Key, info.Name))
+			}
+
+			return decodeDeepObject(request, handleError, info.deepObject, node, info.Key, args.FieldByIndex(info.Index))
+		})
+	}
+
+	for _, key := range sortedKeys(params["request"]) {
+		key, info := key, params["request"][key]
+		decoders = append(decoders, func(request RequestAdapter, args reflect.Value) error {
+			args.FieldByIndex(info.Index).SetString(getRequestInfo(request, key, trustedProxies))
+			return nil
+		})
+	}
+
+	return decoders
+}
+
+func compileBodyDecoder(handleError ErrorHandler, contentType string, info tagInfo) fieldDecoder {
+	if contentType == "application/octet-stream" {
+		return func(request RequestAdapter, args reflect.Value) error {
+			args.FieldByIndex(info.Index).SetBytes(request.GetBody())
+			return nil
+		}
+	}
+
+	return func(request RequestAdapter, args reflect.Value) error {
+		err := json.Unmarshal(request.GetBody(), args.FieldByIndex(info.Index).Addr().Interface())
+		if err != nil {
+			return handleError(request, newBodyError(info.Name, err))
+		}
+		return nil
+	}
+}
+
+// compileParamDecoder builds the decoder for the params read as strings,
+// i.e. the `path`, `header`, `query` and `cookie` params.
+func compileParamDecoder(
+	handleError ErrorHandler,
+	source string,
+	key string,
+	info tagInfo,
+	get func(request RequestAdapter, key string) string,
+) fieldDecoder {
+	return func(request RequestAdapter, args reflect.Value) error {
+		param := get(request, key)
+		if param == "" {
+			// Path params have no defaults and are always required:
+			param = info.Default
+		}
+		if param == "" {
+			if info.Required {
+				return handleError(request, newMissingValueError(source, key, info.Name))
+			}
+			return nil
+		}
+
+		err := setDecodedValue(args.FieldByIndex(info.Index), param)
+		if err != nil {
+			return handleError(request, newConversionError(source, key, info, err))
+		}
+		return nil
+	}
+}
+
+// setDecodedValue works as decodeType but stores the value directly
+// on the field, which avoids allocating a reflect.Value per request.
+func setDecodedValue(field reflect.Value, v string) error {
+	switch field.Kind() {
+	case reflect.Int:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(v, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+
+	case reflect.Uint:
+		i, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return err
+		}
+		field.SetUint(i)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(v, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(i)
+
+	default:
+		field.SetString(v)
+	}
+
+	return nil
+}
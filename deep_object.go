@@ -0,0 +1,274 @@
+package kapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// deepObjectInfo describes how to decode query params using the OpenAPI
+// `deepObject` style, e.g. `?filter[status]=active&filter[created_at][gte]=2024-01-01`,
+// into either a map[string]string or a struct whose fields are tagged with `query`:
+//
+//	type Filter struct {
+//	  Status    string `query:"status"`
+//	  CreatedAt struct {
+//	    Gte string `query:"gte"`
+//	    Lte string `query:"lte"`
+//	  } `query:"created_at"`
+//	}
+//
+//	args struct {
+//	  Filter Filter `query:"filter,deepObject"`
+//	}
+type deepObjectInfo struct {
+	// isMap is true for map[string]string fields, in this case
+	// the other attributes are not used
+	isMap bool
+
+	fields []deepObjectField
+}
+
+type deepObjectField struct {
+	info tagInfo
+
+	// nested is not nil for struct and map fields
+	nested *deepObjectInfo
+}
+
+// compileDeepObject validates the type of a field tagged with `deepObject`
+// and caches the information required for decoding it later.
+func compileDeepObject(t reflect.Type, name string) (_ *deepObjectInfo, problems []string) {
+	if t.Kind() == reflect.Map {
+		if t.Key().Kind() != reflect.String || t.Elem().Kind() != reflect.String {
+			return nil, []string{fmt.Sprintf(
+				"deepObject field %s must be a struct or a map[string]string, but it is %v", name, t,
+			)}
+		}
+		return &deepObjectInfo{isMap: true}, nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, []string{fmt.Sprintf(
+			"deepObject field %s must be a struct or a map[string]string, but it is %v", name, t,
+		)}
+	}
+
+	info := deepObjectInfo{}
+	usedKeys := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldName := name + "." + field.Name
+
+		opts := strings.Split(field.Tag.Get("query"), ",")
+		key := opts[0]
+		if key == "" {
+			continue
+		}
+
+		if !field.IsExported() {
+			problems = append(problems, fmt.Sprintf(
+				"field %s is tagged with `query` but it is not exported", fieldName,
+			))
+			continue
+		}
+
+		if otherField, found := usedKeys[key]; found {
+			problems = append(problems, fmt.Sprintf(
+				"fields %s and %s are both reading the query param '%s'", otherField, fieldName, key,
+			))
+			continue
+		}
+		usedKeys[key] = fieldName
+
+		fieldInfo := deepObjectField{
+			info: tagInfo{
+				Index:   []int{i},
+				Name:    fieldName,
+				Key:     key,
+				Kind:    field.Type.Kind(),
+				Type:    field.Type,
+				Default: field.Tag.Get("default"),
+			},
+		}
+		_, fieldInfo.info.hasDefault = field.Tag.Lookup("default")
+
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "required":
+				fieldInfo.info.Required = true
+			case "optional":
+			default:
+				problems = append(problems, fmt.Sprintf(
+					"unknown option '%s' on the query tag of field %s", opt, fieldName,
+				))
+			}
+		}
+
+		if !isDecodableKind(field.Type.Kind()) {
+			nested, nestedProblems := compileDeepObject(field.Type, fieldName)
+			problems = append(problems, nestedProblems...)
+			fieldInfo.nested = nested
+			if fieldInfo.info.hasDefault {
+				problems = append(problems, fmt.Sprintf(
+					"deepObject fields can't have a default value, but field %s has one", fieldName,
+				))
+			}
+		} else if fieldInfo.info.hasDefault {
+			problems = append(problems, checkDefaultValue(
+				field.Type, fieldName, fieldInfo.info.Default, fieldInfo.info.Required,
+			)...)
+		}
+
+		info.fields = append(info.fields, fieldInfo)
+	}
+
+	return &info, problems
+}
+
+// deepObjectNode is a tree built from the query params, where
+// each bracket of the param name is a level of the tree.
+type deepObjectNode struct {
+	hasValue bool
+	value    string
+	children map[string]*deepObjectNode
+}
+
+// parseDeepObject builds the tree of all query params starting with `key[`,
+// it returns nil if there are no such params on the request.
+func parseDeepObject(request RequestAdapter, key string) *deepObjectNode {
+	var root *deepObjectNode
+	prefix := key + "["
+	request.VisitQueryParams(func(name string, value string) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+
+		if root == nil {
+			root = &deepObjectNode{}
+		}
+
+		node := root
+		rest := name[len(key):]
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				// Malformed params are ignored
+				return
+			}
+
+			segment := rest[1:end]
+			rest = rest[end+1:]
+
+			if node.children == nil {
+				node.children = map[string]*deepObjectNode{}
+			}
+			child := node.children[segment]
+			if child == nil {
+				child = &deepObjectNode{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+
+		// Just like GetQueryParam only the first value is kept:
+		if !node.hasValue {
+			node.hasValue = true
+			node.value = value
+		}
+	})
+
+	return root
+}
+
+// decodeDeepObject fills the target value using the tree of params,
+// the path is the name of the param so far, e.g. `filter[created_at]`,
+// and it is used for building error messages.
+func decodeDeepObject(
+	request RequestAdapter,
+	handleError ErrorHandler,
+	info *deepObjectInfo,
+	node *deepObjectNode,
+	path string,
+	target reflect.Value,
+) error {
+	if info.isMap {
+		if node == nil || len(node.children) == 0 {
+			return nil
+		}
+
+		m := reflect.MakeMapWithSize(target.Type(), len(node.children))
+		flattenDeepObject(node, "", func(key string, value string) {
+			m.SetMapIndex(
+				reflect.ValueOf(key).Convert(target.Type().Key()),
+				reflect.ValueOf(value).Convert(target.Type().Elem()),
+			)
+		})
+		target.Set(m)
+		return nil
+	}
+
+	for _, field := range info.fields {
+		var child *deepObjectNode
+		if node != nil {
+			child = node.children[field.info.Key]
+		}
+		fieldPath := path + "[" + field.info.Key + "]"
+
+		if field.nested != nil {
+			err := decodeDeepObject(request, handleError, field.nested, child, fieldPath, target.FieldByIndex(field.info.Index))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		param := ""
+		if child != nil {
+			param = child.value
+		}
+		if param == "" {
+			param = field.info.Default
+		}
+		if param == "" {
+			if field.info.Required {
+				return handleError(request, newMissingValueError("query", fieldPath, field.info.Name))
+			}
+			continue
+		}
+
+		v, err := decodeType(field.info.Type, param)
+		if err != nil {
+			return handleError(request, newConversionError("query", fieldPath, field.info, err))
+		}
+		target.FieldByIndex(field.info.Index).Set(v)
+	}
+
+	return nil
+}
+
+// flattenDeepObject visits all the values of the tree, the keys of the
+// nested values are written using the bracket notation, e.g. `created_at[gte]`
+func flattenDeepObject(node *deepObjectNode, prefix string, visitor func(key string, value string)) {
+	keys := make([]string, 0, len(node.children))
+	for key := range node.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := node.children[key]
+		name := key
+		if prefix != "" {
+			name = prefix + "[" + key + "]"
+		}
+
+		if child.hasValue {
+			visitor(name, child.value)
+		}
+		if len(child.children) > 0 {
+			flattenDeepObject(child, name, visitor)
+		}
+	}
+}
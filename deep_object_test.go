@@ -0,0 +1,250 @@
+package kapi
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type deepObjectFilter struct {
+	Status    string `query:"status"`
+	Limit     int    `query:"limit" default:"10"`
+	CreatedAt struct {
+		Gte string `query:"gte"`
+		Lte string `query:"lte"`
+	} `query:"created_at"`
+	Extra map[string]string `query:"extra"`
+}
+
+func TestParseDeepObject(t *testing.T) {
+	t.Run("should build a tree with one level per bracket", func(t *testing.T) {
+		node := parseDeepObject(&fakeRequest{
+			query: url.Values{
+				"filter[status]":            {"active", "ignored"},
+				"filter[created_at][gte]":   {"2024-01-01"},
+				"filter[created_at][lte]":   {"2024-12-31"},
+				"filter[created_at][gte]]x": {"malformed-but-prefixed"},
+				"other[status]":             {"other"},
+				"filter":                    {"plain"},
+			},
+		}, "filter")
+
+		var visited []string
+		flattenDeepObject(node, "", func(key string, value string) {
+			visited = append(visited, key+"="+value)
+		})
+
+		tt.AssertEqual(t, visited, []string{
+			"created_at[gte]=2024-01-01",
+			"created_at[lte]=2024-12-31",
+			// Just like GetQueryParam only the first value is kept:
+			"status=active",
+		})
+	})
+
+	t.Run("should ignore params with unclosed brackets", func(t *testing.T) {
+		node := parseDeepObject(&fakeRequest{
+			query: url.Values{
+				"filter[status":   {"active"},
+				"filter[a][b":     {"1"},
+				"filter[valid]":   {"yes"},
+				"filterstatus]":   {"no"},
+				"filter[]":        {"empty"},
+				"filter[x][]":     {"empty-nested"},
+				"filter[y][z]abc": {"suffix"},
+			},
+		}, "filter")
+
+		var visited []string
+		flattenDeepObject(node, "", func(key string, value string) {
+			visited = append(visited, key+"="+value)
+		})
+
+		tt.AssertEqual(t, visited, []string{
+			"=empty",
+			"valid=yes",
+			"x[]=empty-nested",
+			"y[z]=suffix",
+		})
+	})
+
+	t.Run("should return nil when no params use the key", func(t *testing.T) {
+		node := parseDeepObject(&fakeRequest{
+			query: url.Values{"filter": {"x"}, "filters[a]": {"1"}},
+		}, "filter")
+		tt.AssertEqual(t, node == nil, true)
+	})
+}
+
+func TestDecodeDeepObject(t *testing.T) {
+	t.Run("should decode nested structs and maps", func(t *testing.T) {
+		type args struct {
+			Filter deepObjectFilter  `query:"filter,deepObject"`
+			Sort   map[string]string `query:"sort,deepObject"`
+		}
+
+		decoded, err := decodeArgs(&fakeRequest{
+			query: url.Values{
+				"filter[status]":          {"active"},
+				"filter[created_at][gte]": {"2024-01-01"},
+				"filter[extra][a]":        {"1"},
+				"filter[extra][b][c]":     {"2"},
+				"sort[name]":              {"asc"},
+			},
+		}, args{})
+		tt.AssertNoErr(t, err)
+
+		expected := args{
+			Sort: map[string]string{"name": "asc"},
+		}
+		expected.Filter.Status = "active"
+		expected.Filter.Limit = 10
+		expected.Filter.CreatedAt.Gte = "2024-01-01"
+		expected.Filter.Extra = map[string]string{"a": "1", "b[c]": "2"}
+		tt.AssertEqual(t, decoded, expected)
+	})
+
+	t.Run("should apply the defaults when the param is missing", func(t *testing.T) {
+		decoded, err := decodeArgs(&fakeRequest{}, struct {
+			Filter deepObjectFilter `query:"filter,deepObject"`
+		}{})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, decoded.(struct {
+			Filter deepObjectFilter `query:"filter,deepObject"`
+		}).Filter.Limit, 10)
+	})
+
+	t.Run("should fail when a required deepObject is missing", func(t *testing.T) {
+		_, err := decodeArgs(&fakeRequest{
+			query: url.Values{"filter": {"not-a-deep-object"}},
+		}, struct {
+			Filter map[string]string `query:"filter,deepObject,required"`
+		}{})
+		tt.AssertErrContains(t, err, "400", "required query param 'filter' is empty")
+	})
+
+	t.Run("should fail when a required nested field is missing", func(t *testing.T) {
+		type args struct {
+			Filter struct {
+				Range struct {
+					Gte string `query:"gte,required"`
+				} `query:"range"`
+			} `query:"filter,deepObject"`
+		}
+
+		_, err := decodeArgs(&fakeRequest{
+			query: url.Values{"filter[range][lte]": {"1"}},
+		}, args{})
+		tt.AssertErrContains(t, err, "400", "required query param 'filter[range][gte]' is empty")
+	})
+
+	t.Run("should fail naming the full param when the value is invalid", func(t *testing.T) {
+		_, err := decodeArgs(&fakeRequest{
+			query: url.Values{"filter[limit]": {"ten"}},
+		}, struct {
+			Filter deepObjectFilter `query:"filter,deepObject"`
+		}{})
+		tt.AssertErrContains(t, err, "400", "could not convert query param 'filter[limit]' to int")
+	})
+
+	tests := []struct {
+		desc          string
+		args          interface{}
+		expectedError string
+	}{
+		{
+			desc: "should report fields of invalid types",
+			args: struct {
+				Filter []string `query:"filter,deepObject"`
+			}{},
+			expectedError: "deepObject field Filter must be a struct or a map[string]string, but it is []string",
+		},
+		{
+			desc: "should report maps of invalid types",
+			args: struct {
+				Filter map[string]int `query:"filter,deepObject"`
+			}{},
+			expectedError: "deepObject field Filter must be a struct or a map[string]string, but it is map[string]int",
+		},
+		{
+			desc: "should report nested fields of invalid types",
+			args: struct {
+				Filter struct {
+					IDs []int `query:"ids"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "deepObject field Filter.IDs must be a struct or a map[string]string, but it is []int",
+		},
+		{
+			desc: "should report duplicated nested keys",
+			args: struct {
+				Filter struct {
+					A string `query:"status"`
+					B string `query:"status"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "fields Filter.A and Filter.B are both reading the query param 'status'",
+		},
+		{
+			desc: "should report unknown options on nested fields",
+			args: struct {
+				Filter struct {
+					Status string `query:"status,optinal"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "unknown option 'optinal' on the query tag of field Filter.Status",
+		},
+		{
+			desc: "should report defaults on deepObject fields",
+			args: struct {
+				Filter map[string]string `query:"filter,deepObject" default:"x"`
+			}{},
+			expectedError: "deepObject fields can't have a default value, but field Filter has one",
+		},
+		{
+			desc: "should report invalid defaults on nested fields",
+			args: struct {
+				Filter struct {
+					Limit int `query:"limit" default:"ten"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "invalid default value for field Filter.Limit",
+		},
+		{
+			desc: "should report defaults on required nested fields",
+			args: struct {
+				Filter struct {
+					Status string `query:"status,required" default:"active"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "field Filter.Status is required so its default value would never be used",
+		},
+		{
+			desc: "should report defaults on nested deepObject fields",
+			args: struct {
+				Filter struct {
+					Range struct {
+						Gte int `query:"gte"`
+					} `query:"range" default:"x"`
+				} `query:"filter,deepObject"`
+			}{},
+			expectedError: "deepObject fields can't have a default value, but field Filter.Range has one",
+		},
+		{
+			desc: "should report deepObject on other sources",
+			args: struct {
+				Filter map[string]string `header:"filter,deepObject"`
+			}{},
+			expectedError: "unknown option 'deepObject' on the header tag of field Filter",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := TryDecodeHandlerFunction(handlerType(test.args), []reflect.Type{contextType})
+			tt.AssertErrContains(t, err, test.expectedError)
+		})
+	}
+}
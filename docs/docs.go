@@ -0,0 +1,136 @@
+// Package docs serves the OpenAPI document of a kapi.Registry together
+// with a Swagger UI page for browsing it, it is kept apart from the kapi
+// package so the programs that don't serve the docs don't depend on the
+// YAML encoder and the HTML templates.
+package docs
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/internal/swaggerui"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed docs.html
+var docsPage string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// Handler serves the OpenAPI document of a Registry together with
+// a Swagger UI page for browsing it, which works offline since the files
+// of Swagger UI are embedded on this library.
+//
+// The adapters offer a handler built on top of it, e.g. `fiber.Docs()`,
+// which serves the document as JSON on paths ending in "/openapi.json",
+// as YAML on paths ending in "/openapi.yaml", the files of Swagger UI
+// on paths ending in their names and the page on any other path.
+type Handler struct {
+	registry *kapi.Registry
+	info     kapi.OpenAPIInfo
+
+	once sync.Once
+	json []byte
+	yaml []byte
+	page []byte
+	err  error
+}
+
+// NewHandler returns a Handler for the routes of the registry,
+// the document is generated once on the first request, so the routes
+// registered after that are not described.
+func NewHandler(registry *kapi.Registry, info kapi.OpenAPIInfo) *Handler {
+	return &Handler{
+		registry: registry,
+		info:     info,
+	}
+}
+
+// Serve writes the JSON or the YAML document, one of the files
+// of Swagger UI or the docs page, depending on the path of the request.
+func (h *Handler) Serve(request kapi.RequestAdapter) error {
+	h.once.Do(h.build)
+	if h.err != nil {
+		return request.NewHTTPError(http.StatusInternalServerError, "could not build the OpenAPI document: "+h.err.Error())
+	}
+
+	contentType, body := "text/html; charset=utf-8", h.page
+	switch path := request.GetPath(); {
+	case strings.HasSuffix(path, "/openapi.json"):
+		contentType, body = "application/json", h.json
+	case strings.HasSuffix(path, "/openapi.yaml"):
+		contentType, body = "application/yaml", h.yaml
+	case strings.HasSuffix(path, "/swagger-ui-bundle.js"):
+		contentType, body = "text/javascript; charset=utf-8", swaggerui.BundleJS
+	case strings.HasSuffix(path, "/swagger-ui.css"):
+		contentType, body = "text/css; charset=utf-8", swaggerui.CSS
+	case strings.HasSuffix(path, "/favicon-32x32.png"):
+		contentType, body = "image/png", swaggerui.Favicon32
+	}
+
+	request.SetStatus(http.StatusOK)
+	request.SetHeader("Content-Type", contentType)
+	return request.WriteBody(body)
+}
+
+func (h *Handler) build() {
+	doc := h.registry.OpenAPI(h.info)
+
+	h.json, h.err = json.Marshal(doc)
+	if h.err != nil {
+		return
+	}
+
+	h.yaml, h.err = jsonToYAML(h.json)
+	if h.err != nil {
+		return
+	}
+
+	var page bytes.Buffer
+	h.err = docsTemplate.Execute(&page, struct {
+		Title string
+		Spec  template.JS
+	}{
+		Title: h.info.Title,
+		// json.Marshal escapes the HTML characters so the
+		// document can be safely embedded on the page:
+		Spec: template.JS(h.json),
+	})
+	h.page = page.Bytes()
+}
+
+// jsonToYAML converts the JSON into block style YAML keeping the order of the keys
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	err := yaml.Unmarshal(data, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	clearStyle(&node)
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&node)
+	if err == nil {
+		err = encoder.Close()
+	}
+	return b.Bytes(), err
+}
+
+// clearStyle removes the flow style and the quotes
+// copied from the JSON, the quotes are added back
+// by the encoder only where they are required.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
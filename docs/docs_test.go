@@ -0,0 +1,102 @@
+package docs_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	"github.com/vingarcia/kapi/docs"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+func TestHandler(t *testing.T) {
+	registry := kapi.NewRegistry()
+	registry.Register("GET", "/users/{id}", kapi.DecodeHandlerFunction(
+		reflect.TypeOf(func(ctx context.Context, args struct {
+			ID int `path:"id"`
+		}) error {
+			return nil
+		}),
+		[]reflect.Type{reflect.TypeOf(new(context.Context)).Elem()},
+	))
+	handler := docs.NewHandler(registry, kapi.OpenAPIInfo{
+		Title:   "Users </script> API",
+		Version: "1.0.0",
+	})
+
+	tests := []struct {
+		desc                string
+		path                string
+		expectedContentType string
+		expectedContent     []string
+	}{
+		{
+			desc:                "should serve the page on the prefix",
+			path:                "/docs",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedContent: []string{
+				"<title>Users &lt;/script&gt; API</title>",
+				`"/users/{id}"`,
+				`SwaggerUIBundle({`,
+				`base + "swagger-ui-bundle.js"`,
+			},
+		},
+		{
+			desc:                "should serve the page on other paths",
+			path:                "/docs/",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedContent:     []string{"SwaggerUIBundle({"},
+		},
+		{
+			desc:                "should serve the JSON document",
+			path:                "/docs/openapi.json",
+			expectedContentType: "application/json",
+			expectedContent:     []string{`"openapi":"3.1.0"`, `"/users/{id}"`},
+		},
+		{
+			desc:                "should serve the YAML document",
+			path:                "/docs/openapi.yaml",
+			expectedContentType: "application/yaml",
+			expectedContent:     []string{"openapi: 3.1.0", "/users/{id}:"},
+		},
+		{
+			desc:                "should serve the Swagger UI bundle",
+			path:                "/docs/swagger-ui-bundle.js",
+			expectedContentType: "text/javascript; charset=utf-8",
+			expectedContent:     []string{"SwaggerUIBundle"},
+		},
+		{
+			desc:                "should serve the Swagger UI styles",
+			path:                "/docs/swagger-ui.css",
+			expectedContentType: "text/css; charset=utf-8",
+			expectedContent:     []string{".swagger-ui"},
+		},
+		{
+			desc:                "should serve the favicon",
+			path:                "/docs/favicon-32x32.png",
+			expectedContentType: "image/png",
+			expectedContent:     []string{"\x89PNG"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			resp, err := kapitest.Request().URL(test.path).Serve(handler.Serve)
+			tt.AssertNoErr(t, err)
+
+			tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+			tt.AssertEqual(t, resp.Headers.Get("Content-Type"), test.expectedContentType)
+			tt.AssertContains(t, string(resp.Body), test.expectedContent...)
+		})
+	}
+
+	t.Run("should escape the document embedded on the page", func(t *testing.T) {
+		resp, err := kapitest.Request().URL("/docs").Serve(handler.Serve)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, strings.Count(string(resp.Body), "</script>"), 2)
+	})
+}
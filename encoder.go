@@ -0,0 +1,277 @@
+package kapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EncodeRequest builds an HTTP request from an args struct doing the inverse
+// of UnmarshalRequestAsStruct, i.e. the `path` params are substituted on the
+// pattern, the `header`, `query` and `cookie` params are set on the request
+// and the Body is encoded according to its content type, e.g.:
+//
+//	req, err := kapi.EncodeRequest("GET", "http://localhost:8080/users/{id}", GetUserArgs{
+//	  ID: 42,
+//	})
+//
+// The path params can be written on the pattern as `{id}`, `:id`, `<id>` or `*`,
+// so the same patterns used for registering the routes can be informed.
+//
+// The optional params are omitted when they are empty, see ParamSpec.OmitsZeroValue,
+// and the `context` and `request` fields are ignored since these values are not
+// sent by the clients.
+func EncodeRequest(method string, pattern string, args any) (*http.Request, error) {
+	v := reflect.ValueOf(args)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("kapi: the args must be a struct or a non-nil pointer to a struct, but it is of type %T", args)
+	}
+
+	info, err := getEncoderInfo(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := info.buildURL(pattern, v)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if info.body != nil {
+		field := v.FieldByIndex(info.body.Index)
+		if info.bodyContentType == "application/octet-stream" {
+			body = bytes.NewReader(field.Bytes())
+		} else {
+			b, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("kapi: could not encode field %s as JSON: %w", info.body.Name, err)
+			}
+			body = bytes.NewReader(b)
+		}
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if info.body != nil {
+		req.Header.Set("Content-Type", info.bodyContentType)
+	}
+
+	for _, param := range info.params["header"] {
+		visitParam(param, v, req.Header.Set, req.Header.Add)
+	}
+	for _, param := range info.params["cookie"] {
+		visitParam(param, v, func(name string, value string) {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}, nil)
+	}
+
+	return req, nil
+}
+
+// encoderInfo caches the information about the args struct used by EncodeRequest
+type encoderInfo struct {
+	bodyContentType string
+	body            *tagInfo
+
+	// params contains the path, header, query and cookie params
+	// including the catch-all and deepObject params
+	params map[string][]tagInfo
+}
+
+var encoderInfoCache sync.Map
+
+func getEncoderInfo(t reflect.Type) (*encoderInfo, error) {
+	if info, found := encoderInfoCache.Load(t); found {
+		return info.(*encoderInfo), nil
+	}
+
+	var problems []string
+	bodyContentType, bodyInfo, err := getBodyInfo(t)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	params, tagProblems := getTagNames(t)
+	problems = append(problems, tagProblems...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf(
+			"kapi: invalid args struct of type %v:\n - %s",
+			t, strings.Join(problems, "\n - "),
+		)
+	}
+
+	info := encoderInfo{
+		bodyContentType: bodyContentType,
+		body:            bodyInfo,
+		params:          map[string][]tagInfo{},
+	}
+	for _, source := range []string{"path", "header", "query", "cookie"} {
+		for _, key := range sortedKeys(params[source]) {
+			info.params[source] = append(info.params[source], params[source][key])
+		}
+	}
+
+	encoderInfoCache.Store(t, &info)
+	return &info, nil
+}
+
+// patternParamRegex matches the path params written as `{id}`, `:id`, `<id:\d+>`
+// or as the greedy `*` and `+` params of fiber, the name of the param
+// is on the first, the second, the third or the fourth group respectively.
+var patternParamRegex = regexp.MustCompile(`\{([^}]+)\}|:([^?:/\-.*+]+)\??|<([^:>]+)(?::[^>]*)?>|([*+]\d*)`)
+
+// buildURL substitutes the path params on the pattern and adds the query params
+func (e *encoderInfo) buildURL(pattern string, args reflect.Value) (string, error) {
+	// The scheme and the host are kept as they are so the
+	// port is not mistaken for a param, e.g. "localhost:8080":
+	prefix := ""
+	if i := strings.Index(pattern, "://"); i != -1 {
+		hostEnd := strings.Index(pattern[i+3:], "/")
+		if hostEnd == -1 {
+			hostEnd = len(pattern) - i - 3
+		}
+		prefix, pattern = pattern[:i+3+hostEnd], pattern[i+3+hostEnd:]
+	}
+
+	pattern, rawQuery, _ := strings.Cut(pattern, "?")
+
+	pathParams := map[string]tagInfo{}
+	for _, param := range e.params["path"] {
+		pathParams[param.Key] = param
+	}
+
+	var problems []string
+	path := patternParamRegex.ReplaceAllStringFunc(pattern, func(match string) string {
+		groups := patternParamRegex.FindStringSubmatch(match)
+		key := groups[1] + groups[2] + groups[3] + groups[4]
+
+		param, found := pathParams[key]
+		if !found {
+			problems = append(problems, fmt.Sprintf("the pattern has the param '%s' but no field reads it", key))
+			return match
+		}
+		delete(pathParams, key)
+
+		value := formatParam(args.FieldByIndex(param.Index))
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("path param '%s' is empty", key))
+		}
+
+		// Greedy params might contain slashes so they are not escaped:
+		if strings.HasPrefix(key, "*") || strings.HasPrefix(key, "+") {
+			return value
+		}
+		return url.PathEscape(value)
+	})
+
+	for _, key := range sortedKeys(pathParams) {
+		problems = append(problems, fmt.Sprintf(
+			"field %s reads the path param '%s' but the pattern has no such param", pathParams[key].Name, key,
+		))
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("kapi: could not encode the request for '%s':\n - %s", prefix+pattern, strings.Join(problems, "\n - "))
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("kapi: invalid query on the pattern '%s': %w", prefix+pattern, err)
+	}
+	for _, param := range e.params["query"] {
+		visitParam(param, args, query.Set, query.Add)
+	}
+
+	target := prefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target, nil
+}
+
+// visitParam passes the values of the param to set, or to add for
+// the multi value catch-all params, omitting the empty optional params
+func visitParam(param tagInfo, args reflect.Value, set func(key string, value string), add func(key string, value string)) {
+	field := args.FieldByIndex(param.Index)
+	switch {
+	case param.CatchAll:
+		iter := field.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if iter.Value().Kind() != reflect.Slice {
+				set(key, iter.Value().String())
+				continue
+			}
+			for i := 0; i < iter.Value().Len(); i++ {
+				if add == nil {
+					set(key, iter.Value().Index(i).String())
+					continue
+				}
+				add(key, iter.Value().Index(i).String())
+			}
+		}
+	case param.deepObject != nil:
+		visitDeepObject(param.deepObject, param.Key, field, set)
+	case shouldEncode(param, field):
+		set(param.Key, formatParam(field))
+	}
+}
+
+// visitDeepObject passes the fields of the deepObject to set
+// using the bracket notation, e.g. `filter[status]=active`
+func visitDeepObject(info *deepObjectInfo, key string, v reflect.Value, set func(key string, value string)) {
+	if info.isMap {
+		iter := v.MapRange()
+		for iter.Next() {
+			set(key+"["+iter.Key().String()+"]", iter.Value().String())
+		}
+		return
+	}
+
+	for _, field := range info.fields {
+		fieldValue := v.FieldByIndex(field.info.Index)
+		fieldKey := key + "[" + field.info.Key + "]"
+		switch {
+		case field.nested != nil:
+			visitDeepObject(field.nested, fieldKey, fieldValue, set)
+		case shouldEncode(field.info, fieldValue):
+			set(fieldKey, formatParam(fieldValue))
+		}
+	}
+}
+
+func shouldEncode(info tagInfo, v reflect.Value) bool {
+	return !v.IsZero() || !omitsZeroValue(info.Required, info.Default, v.Kind())
+}
+
+// omitsZeroValue reports whether the empty value of a param is omitted from
+// the requests, which is true for the optional params, except for the zero
+// numbers of params with defaults, since omitting them would make the decoder
+// use the default instead. It is shared with the generated clients.
+func omitsZeroValue(required bool, defaultValue string, kind reflect.Kind) bool {
+	return !required && (defaultValue == "" || kind == reflect.String)
+}
+
+// formatParam converts the value into a string the same way decodeType parses it
+func formatParam(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return v.String()
+}
@@ -0,0 +1,358 @@
+package kapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+	"github.com/vingarcia/kapi/kapitest"
+)
+
+type encoderFilter struct {
+	Status string `query:"status"`
+	Limit  int    `query:"limit" default:"10"`
+	Range  struct {
+		Gte string `query:"gte"`
+	} `query:"range"`
+}
+
+type encoderUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestEncodeRequest(t *testing.T) {
+	tests := []struct {
+		desc         string
+		method       string
+		pattern      string
+		args         interface{}
+		expectedURL  string
+		pathParams   map[string]string
+		expectedArgs interface{}
+	}{
+		{
+			desc:    "should substitute params written as {id}",
+			method:  "GET",
+			pattern: "/orgs/{org}/users/{id}",
+			args: struct {
+				Org string `path:"org"`
+				ID  int    `path:"id"`
+			}{Org: "fake org/1", ID: 42},
+			expectedURL: "/orgs/fake%20org%2F1/users/42",
+			pathParams:  map[string]string{"org": "fake org/1", "id": "42"},
+		},
+		{
+			desc:    "should substitute params written as :id",
+			method:  "GET",
+			pattern: "/orgs/:org/users/:id?",
+			args: struct {
+				Org int8   `path:"org"`
+				ID  uint16 `path:"id"`
+			}{Org: -3, ID: 7},
+			expectedURL: "/orgs/-3/users/7",
+			pathParams:  map[string]string{"org": "-3", "id": "7"},
+		},
+		{
+			desc:    "should substitute params written as <id:\\d+>",
+			method:  "GET",
+			pattern: `/orgs/<org>/users/<id:\d+>`,
+			args: struct {
+				Org string `path:"org"`
+				ID  uint64 `path:"id"`
+			}{Org: "fake-org", ID: 42},
+			expectedURL: "/orgs/fake-org/users/42",
+			pathParams:  map[string]string{"org": "fake-org", "id": "42"},
+		},
+		{
+			desc:    "should substitute greedy params without escaping them",
+			method:  "PUT",
+			pattern: "/files/*",
+			args: struct {
+				Path string `path:"*"`
+				Body []byte `content-type:"application/octet-stream"`
+			}{Path: "a/b.txt", Body: []byte("fake content")},
+			expectedURL: "/files/a/b.txt",
+			pathParams:  map[string]string{"*": "a/b.txt"},
+		},
+		{
+			desc:    "should keep the scheme, the host and the port",
+			method:  "GET",
+			pattern: "http://localhost:8080/users/:id",
+			args: struct {
+				ID int `path:"id"`
+			}{ID: 42},
+			expectedURL: "http://localhost:8080/users/42",
+			pathParams:  map[string]string{"id": "42"},
+		},
+		{
+			desc:    "should keep the hosts without paths",
+			method:  "GET",
+			pattern: "https://example.com:8443",
+			args: struct {
+				Page int `query:"page"`
+			}{Page: 2},
+			expectedURL: "https://example.com:8443?page=2",
+		},
+		{
+			desc:    "should merge the query params with the query of the pattern",
+			method:  "GET",
+			pattern: "/users?sort=name&page=1",
+			args: struct {
+				Page   int    `query:"page"`
+				Size   uint8  `query:"size"`
+				Cursor string `query:"cursor"`
+				Sort   string `query:"sort"`
+			}{Page: 2, Size: 20},
+			expectedURL: "/users?page=2&size=20&sort=name",
+			expectedArgs: struct {
+				Page   int    `query:"page"`
+				Size   uint8  `query:"size"`
+				Cursor string `query:"cursor"`
+				Sort   string `query:"sort"`
+			}{Page: 2, Size: 20, Sort: "name"},
+		},
+		{
+			desc:    "should send the zero numbers of params with defaults",
+			method:  "GET",
+			pattern: "/users",
+			args: struct {
+				Page int    `query:"page" default:"1"`
+				Size int    `query:"size"`
+				Sort string `query:"sort" default:"name"`
+			}{},
+			expectedURL: "/users?page=0",
+			expectedArgs: struct {
+				Page int    `query:"page" default:"1"`
+				Size int    `query:"size"`
+				Sort string `query:"sort" default:"name"`
+			}{
+				// Empty strings are omitted, so the default is used instead:
+				Sort: "name",
+			},
+		},
+		{
+			desc:    "should encode deepObject params",
+			method:  "GET",
+			pattern: "/users",
+			args: func() interface{} {
+				args := struct {
+					Filter encoderFilter     `query:"filter,deepObject"`
+					Sort   map[string]string `query:"sort,deepObject"`
+				}{
+					Sort: map[string]string{"name": "asc", "age": "desc"},
+				}
+				args.Filter.Status = "active"
+				args.Filter.Range.Gte = "2024-01-01"
+				return args
+			}(),
+			expectedURL: "/users?filter%5Blimit%5D=0&filter%5Brange%5D%5Bgte%5D=2024-01-01&filter%5Bstatus%5D=active&sort%5Bage%5D=desc&sort%5Bname%5D=asc",
+		},
+		{
+			desc:    "should encode catch-all params",
+			method:  "GET",
+			pattern: "/users",
+			args: struct {
+				Meta  map[string]string `header:"X-Meta-*"`
+				Trace http.Header       `header:"X-Trace-*"`
+				Tags  url.Values        `query:"tag_*"`
+			}{
+				Meta:  map[string]string{"X-Meta-Region": "us"},
+				Trace: http.Header{"X-Trace-Id": {"1", "2"}},
+				Tags:  url.Values{"tag_a": {"1", "2"}, "tag_b": {"3"}},
+			},
+			expectedURL: "/users?tag_a=1&tag_a=2&tag_b=3",
+		},
+		{
+			desc:    "should encode headers and cookies",
+			method:  "GET",
+			pattern: "/users",
+			args: struct {
+				Token   string `header:"Authorization"`
+				Version int    `header:"X-Version,optional"`
+				Session string `cookie:"session"`
+				Theme   string `cookie:"theme,optional"`
+				Visits  uint   `cookie:"visits,optional"`
+			}{Token: "Bearer fake", Session: "fake-session", Visits: 3},
+			expectedURL: "/users",
+		},
+		{
+			desc:    "should encode JSON bodies",
+			method:  "POST",
+			pattern: "/orgs/:org/users",
+			args: struct {
+				Org  int `path:"org"`
+				Body encoderUser
+			}{Org: 3, Body: encoderUser{ID: 7, Name: "fake-name"}},
+			expectedURL: "/orgs/3/users",
+			pathParams:  map[string]string{"org": "3"},
+		},
+		{
+			desc:    "should ignore context values and request info",
+			method:  "GET",
+			pattern: "/users",
+			args: struct {
+				User   string `context:"user,optional"`
+				Method string `request:"method"`
+			}{User: "fake-user", Method: "POST"},
+			expectedURL: "/users",
+			expectedArgs: struct {
+				User   string `context:"user,optional"`
+				Method string `request:"method"`
+			}{Method: "GET"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			req, err := kapi.EncodeRequest(test.method, test.pattern, test.args)
+			tt.AssertNoErr(t, err)
+			tt.AssertEqual(t, req.Method, test.method)
+			tt.AssertEqual(t, req.URL.String(), test.expectedURL)
+
+			expectedArgs := test.expectedArgs
+			if expectedArgs == nil {
+				expectedArgs = test.args
+			}
+
+			decoded := decodeEncodedRequest(t, req, test.pathParams, reflect.TypeOf(test.args))
+			tt.AssertEqual(t, decoded, expectedArgs)
+		})
+	}
+
+	t.Run("should accept pointers to structs", func(t *testing.T) {
+		req, err := kapi.EncodeRequest("POST", "/users", &struct {
+			Body encoderUser `content-type:"application/json"`
+		}{Body: encoderUser{Name: "fake-name"}})
+		tt.AssertNoErr(t, err)
+
+		body, err := io.ReadAll(req.Body)
+		tt.AssertNoErr(t, err)
+		tt.AssertEqual(t, req.Header.Get("Content-Type"), "application/json")
+		tt.AssertEqual(t, string(body), `{"id":0,"name":"fake-name"}`)
+	})
+}
+
+func TestEncodeRequestErrors(t *testing.T) {
+	var nilArgs *struct{}
+
+	tests := []struct {
+		desc           string
+		pattern        string
+		args           interface{}
+		expectedErrors []string
+	}{
+		{
+			desc:           "should report args that are not structs",
+			pattern:        "/users",
+			args:           42,
+			expectedErrors: []string{"the args must be a struct or a non-nil pointer to a struct, but it is of type int"},
+		},
+		{
+			desc:           "should report nil pointers",
+			pattern:        "/users",
+			args:           nilArgs,
+			expectedErrors: []string{"but it is of type *struct {}"},
+		},
+		{
+			desc:    "should report invalid args structs",
+			pattern: "/users",
+			args: struct {
+				ID int `path:"id,unknown"`
+			}{},
+			expectedErrors: []string{"invalid args struct of type", "unknown option 'unknown' on the path tag of field ID"},
+		},
+		{
+			desc:    "should report params of the pattern that no field reads",
+			pattern: "/orgs/{org}/users/:id",
+			args: struct {
+				ID int `path:"id"`
+			}{ID: 1},
+			expectedErrors: []string{"could not encode the request for '/orgs/{org}/users/:id'", "the pattern has the param 'org' but no field reads it"},
+		},
+		{
+			desc:    "should report path params missing on the pattern",
+			pattern: "http://localhost:8080/users",
+			args: struct {
+				ID int `path:"id"`
+			}{ID: 1},
+			expectedErrors: []string{"field ID reads the path param 'id' but the pattern has no such param"},
+		},
+		{
+			desc:    "should report empty path params",
+			pattern: "/users/<name>",
+			args: struct {
+				Name string `path:"name"`
+			}{},
+			expectedErrors: []string{"path param 'name' is empty"},
+		},
+		{
+			desc:           "should report invalid queries on the pattern",
+			pattern:        "/users?a=%zz",
+			args:           struct{}{},
+			expectedErrors: []string{"invalid query on the pattern '/users'"},
+		},
+		{
+			desc:    "should report bodies that can't be encoded",
+			pattern: "/users",
+			args: struct {
+				Body map[string]interface{}
+			}{Body: map[string]interface{}{"fn": func() {}}},
+			expectedErrors: []string{"could not encode field Body as JSON"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := kapi.EncodeRequest("GET", test.pattern, test.args)
+			tt.AssertErrContains(t, err, test.expectedErrors...)
+		})
+	}
+}
+
+// decodeEncodedRequest decodes the request with kapitest into a new value of argsType,
+// the path params are informed separately since kapitest doesn't have a router.
+func decodeEncodedRequest(t *testing.T, req *http.Request, pathParams map[string]string, argsType reflect.Type) interface{} {
+	b := kapitest.Request().
+		Method(req.Method).
+		URL(req.URL.RequestURI())
+	for key, value := range pathParams {
+		b.Path(key, value)
+	}
+	for key, values := range req.Header {
+		for _, value := range values {
+			b.Header(key, value)
+		}
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		tt.AssertNoErr(t, err)
+		b.Body(body)
+	}
+
+	fnInfo, err := kapi.TryDecodeHandlerFunction(
+		reflect.FuncOf(
+			[]reflect.Type{contextType, argsType},
+			[]reflect.Type{reflect.TypeOf(new(error)).Elem()},
+			false,
+		),
+		[]reflect.Type{contextType},
+	)
+	tt.AssertNoErr(t, err)
+
+	var decoded reflect.Value
+	var decodeErr error
+	_, err = b.Serve(func(request kapi.RequestAdapter) error {
+		decoded, decodeErr = kapi.UnmarshalRequestAsStruct(request, fnInfo)
+		return nil
+	})
+	tt.AssertNoErr(t, err)
+	tt.AssertNoErr(t, decodeErr)
+
+	return decoded.Elem().Interface()
+}
+
+var contextType = reflect.TypeOf(new(context.Context)).Elem()
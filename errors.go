@@ -0,0 +1,93 @@
+package kapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrorReason describes why a request could not be decoded
+type ErrorReason string
+
+const (
+	// ReasonMissingValue means a required value was not present on the request
+	ReasonMissingValue ErrorReason = "missing-value"
+
+	// ReasonInvalidValue means a value was present but could not be
+	// converted into the type of the corresponding struct field
+	ReasonInvalidValue ErrorReason = "invalid-value"
+
+	// ReasonInvalidContextValue means a value stored on the request context
+	// is not compatible with the type of the corresponding struct field,
+	// this is usually caused by a bug on a middleware and not by the client.
+	ReasonInvalidContextValue ErrorReason = "invalid-context-value"
+)
+
+// DecodingError is the typed error produced by kapi when
+// an input request can't be decoded into the handler args struct.
+//
+// It is never returned directly to the framework, instead it is passed
+// to the ErrorHandler (see WithErrorHandler) which decides which error
+// should actually be returned.
+type DecodingError struct {
+	// StatusCode is the status kapi would use by default for this error
+	StatusCode int
+
+	// Source is the tag describing where the value should have been
+	// read from, i.e. "path", "header", "query", "cookie", "context" or "body"
+	Source string
+
+	// Key is the name used on the tag, e.g. "id" for `path:"id"`
+	Key string
+
+	// Field is the name of the struct field that would receive the value
+	Field string
+
+	Reason ErrorReason
+
+	// Message is the default human readable description of the error
+	Message string
+
+	// Err is the underlying error, e.g. a strconv or json error, it might be nil
+	Err error
+}
+
+func (e *DecodingError) Error() string {
+	return fmt.Sprintf("kapi: %s", e.Message)
+}
+
+func (e *DecodingError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHandler receives the decoding errors together with the adapter of the
+// current request and returns the error that should be sent to the framework.
+type ErrorHandler func(request RequestAdapter, err *DecodingError) error
+
+// DefaultErrorHandler is the ErrorHandler used when none is informed,
+// it builds an HTTP error using the default status code and message.
+func DefaultErrorHandler(request RequestAdapter, err *DecodingError) error {
+	return request.NewHTTPError(err.StatusCode, err.Message)
+}
+
+// HandlerError is returned by TryDecodeHandlerFunction
+// and lists every problem found on an invalid handler.
+type HandlerError struct {
+	HandlerType reflect.Type
+	Problems    []string
+}
+
+func newHandlerError(handlerType reflect.Type, problems []string) *HandlerError {
+	return &HandlerError{
+		HandlerType: handlerType,
+		Problems:    problems,
+	}
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf(
+		"kapi: invalid handler of type %v:\n - %s",
+		e.HandlerType,
+		strings.Join(e.Problems, "\n - "),
+	)
+}
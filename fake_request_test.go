@@ -0,0 +1,145 @@
+package kapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+// fakeRequest is a minimal RequestAdapter used by the unit tests
+// of this package, which can't import kapitest since it imports kapi.
+type fakeRequest struct {
+	method     string
+	path       string
+	remoteIP   string
+	pathParams map[string]string
+	headers    http.Header
+	query      url.Values
+	body       []byte
+	ctx        context.Context
+}
+
+type fakeHTTPError struct {
+	statusCode int
+	msg        string
+}
+
+func (e fakeHTTPError) Error() string {
+	return fmt.Sprintf("%d: %s", e.statusCode, e.msg)
+}
+
+func (f *fakeRequest) NewHTTPError(statusCode int, msg string) error {
+	return fakeHTTPError{statusCode: statusCode, msg: msg}
+}
+
+func (f *fakeRequest) GetBody() []byte {
+	return f.body
+}
+
+func (f *fakeRequest) GetPathParam(paramName string) string {
+	return f.pathParams[paramName]
+}
+
+func (f *fakeRequest) GetHeaderParam(paramName string) string {
+	return f.headers.Get(paramName)
+}
+
+func (f *fakeRequest) GetQueryParam(paramName string) string {
+	return f.query.Get(paramName)
+}
+
+func (f *fakeRequest) GetCookie(cookieName string) string {
+	cookie, err := (&http.Request{Header: f.headers}).Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (f *fakeRequest) VisitHeaders(visitor func(key string, value string)) {
+	visitSorted(f.headers, visitor)
+}
+
+func (f *fakeRequest) VisitQueryParams(visitor func(key string, value string)) {
+	visitSorted(f.query, visitor)
+}
+
+func (f *fakeRequest) GetMethod() string {
+	return f.method
+}
+
+func (f *fakeRequest) GetPath() string {
+	return f.path
+}
+
+func (f *fakeRequest) GetHost() string {
+	return "localhost"
+}
+
+func (f *fakeRequest) GetScheme() string {
+	return "http"
+}
+
+func (f *fakeRequest) GetRemoteIP() string {
+	return f.remoteIP
+}
+
+func (f *fakeRequest) GetRawQuery() string {
+	return f.query.Encode()
+}
+
+func (f *fakeRequest) GetRequestURI() string {
+	return f.path + "?" + f.query.Encode()
+}
+
+func (f *fakeRequest) GetContext() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+func (f *fakeRequest) GetContextValue(contextKey any) any {
+	return f.GetContext().Value(contextKey)
+}
+
+func (f *fakeRequest) SetContextValue(contextKey any, value any) {
+	f.ctx = context.WithValue(f.GetContext(), contextKey, value)
+}
+
+func (f *fakeRequest) SetStatus(statusCode int)           {}
+func (f *fakeRequest) SetHeader(key string, value string) {}
+func (f *fakeRequest) SetCookie(cookie Cookie)            {}
+func (f *fakeRequest) WriteBody(body []byte) error        { return nil }
+
+func visitSorted(values map[string][]string, visitor func(key string, value string)) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range values[key] {
+			visitor(key, value)
+		}
+	}
+}
+
+// decodeArgs decodes the request into a new value of the same type as args
+func decodeArgs(request RequestAdapter, args interface{}, opts ...Option) (interface{}, error) {
+	fnInfo, err := TryDecodeHandlerFunction(handlerType(args), []reflect.Type{contextType}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := UnmarshalRequestAsStruct(request, fnInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Elem().Interface(), nil
+}
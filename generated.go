@@ -0,0 +1,119 @@
+package kapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+)
+
+// RequestDecoder is implemented by the args structs whose decoders were
+// generated with the `kapi gen` command, when it is implemented the
+// generated code is used for decoding the requests instead of reflection.
+//
+// The methods below and the exported methods of DecodedHandlerFunction used
+// by them are only meant to be called by the generated code.
+type RequestDecoder interface {
+	DecodeRequest(request RequestAdapter, funcInfo DecodedHandlerFunction) error
+
+	// KapiFingerprint returns the name of the struct and its fingerprint
+	// at the time the decoder was generated, e.g. "GetUserArgs:9f86d081884c7d65",
+	// and it is used for detecting outdated decoders.
+	KapiFingerprint() string
+}
+
+var requestDecoderType = reflect.TypeOf(new(RequestDecoder)).Elem()
+
+// checkGeneratedDecoder reports whether *t implements RequestDecoder,
+// and problems if the decoder was generated for an older version of t.
+func checkGeneratedDecoder(t reflect.Type) (bool, []string) {
+	ptr := reflect.PtrTo(t)
+	if !ptr.Implements(requestDecoderType) {
+		return false, nil
+	}
+
+	// Decoders promoted from embedded structs, e.g. a group of
+	// pagination params, must not be used for the outer struct:
+	decoder := reflect.New(t).Interface().(RequestDecoder)
+	name, fingerprint, _ := strings.Cut(decoder.KapiFingerprint(), ":")
+	if name != t.Name() {
+		return false, nil
+	}
+
+	if fingerprint != StructFingerprint(t) {
+		return false, []string{fmt.Sprintf(
+			"the decoder generated for %v is outdated, please run `go generate` again", t,
+		)}
+	}
+
+	return true, nil
+}
+
+// StructFingerprint hashes the names and the tags of the fields of the args struct,
+// including the fields of embedded and inline structs, and it is used by `kapi gen`
+// for detecting when the struct changes after the decoder was generated.
+func StructFingerprint(t reflect.Type) string {
+	var b strings.Builder
+	writeFingerprint(&b, t, "")
+
+	h := fnv.New64a()
+	h.Write([]byte(b.String()))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func writeFingerprint(b *strings.Builder, t reflect.Type, namePrefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := namePrefix + field.Name
+		fmt.Fprintf(b, "%s %q\n", name, field.Tag)
+
+		_, inline := field.Tag.Lookup("kapi")
+		if (field.Anonymous || inline) && field.Type.Kind() == reflect.Struct {
+			writeFingerprint(b, field.Type, name+".")
+		}
+	}
+}
+
+// MissingValue builds the error for a required value missing from the request
+func (d DecodedHandlerFunction) MissingValue(request RequestAdapter, source string, key string, field string) error {
+	return d.handleError(request, newMissingValueError(source, key, field))
+}
+
+// InvalidValue builds the error for a value that could not be
+// converted into the kind of the field that would receive it.
+func (d DecodedHandlerFunction) InvalidValue(
+	request RequestAdapter,
+	source string,
+	key string,
+	field string,
+	kind reflect.Kind,
+	err error,
+) error {
+	return d.handleError(request, newConversionError(source, key, tagInfo{Name: field, Kind: kind}, err))
+}
+
+// DecodeJSONBody parses the body of the request as JSON into the target
+func (d DecodedHandlerFunction) DecodeJSONBody(request RequestAdapter, field string, target interface{}) error {
+	err := json.Unmarshal(request.GetBody(), target)
+	if err != nil {
+		return d.handleError(request, newBodyError(field, err))
+	}
+	return nil
+}
+
+// DecodeContextValue reads the value stored on the request
+// context for the `context` tag with the informed key.
+func (d DecodedHandlerFunction) DecodeContextValue(request RequestAdapter, key string, target interface{}) error {
+	info, found := d.contextValues[key]
+	if !found {
+		panic(fmt.Sprintf("kapi: the generated decoder for %v reads the unknown context value '%s'", d.structType, key))
+	}
+
+	return decodeContextValue(request, d.handleError, key, info, reflect.ValueOf(target).Elem())
+}
+
+// RequestInfo returns the metadata read with the `request` tag
+func (d DecodedHandlerFunction) RequestInfo(request RequestAdapter, key string) string {
+	return getRequestInfo(request, key, d.trustedProxies)
+}
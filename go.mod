@@ -1,12 +1,21 @@
 module github.com/vingarcia/kapi
 
-go 1.14
+go 1.18
 
 require (
-	github.com/gofiber/fiber/v2 v2.20.1 // indirect
+	github.com/gofiber/fiber/v2 v2.20.1
 	github.com/jackwhelpton/fasthttp-routing/v2 v2.0.0
-	github.com/kyoh86/richgo v0.3.9 // indirect
 	github.com/stretchr/testify v1.8.4
 	github.com/valyala/fasthttp v1.29.0
-	github.com/vingarcia/structscanner v0.0.0-20240113152555-bbf81f0b9f00 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.2 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/klauspost/compress v1.13.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/tcplisten v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20210514084401-e8d321eab015 // indirect
 )
@@ -0,0 +1,24 @@
+// Package swaggerui embeds the files of Swagger UI used by the docs page,
+// copied without changes from the dist folder of Swagger UI 5.18.2, which
+// is distributed by SmartBear Software under the Apache License 2.0.
+//
+// The files are only included on the binaries that serve the docs page,
+// since the linker drops the embedded values when they are not referenced.
+package swaggerui
+
+import _ "embed"
+
+// BundleJS is swagger-ui-bundle.js, which defines SwaggerUIBundle
+//
+//go:embed swagger-ui-bundle.js
+var BundleJS []byte
+
+// CSS is swagger-ui.css
+//
+//go:embed swagger-ui.css
+var CSS []byte
+
+// Favicon32 is favicon-32x32.png
+//
+//go:embed favicon-32x32.png
+var Favicon32 []byte
@@ -47,6 +47,18 @@ func AssertErrContains(t *testing.T, err error, substrs ...string) {
 	}
 }
 
+// AssertContains checks if the str argument contains all
+// the substrs and fails the test with an appropriate error message if not.
+func AssertContains(t *testing.T, str string, substrs ...string) {
+	for _, substr := range substrs {
+		require.True(t,
+			strings.Contains(str, substr),
+			"missing substring '%s' in: '%s'",
+			substr, str,
+		)
+	}
+}
+
 // AssertApproxDuration checks if the durations v1 and v2 are close up to the tolerance specified.
 // The format and args slice can be used for generating an appropriate error message if they are not.
 func AssertApproxDuration(t *testing.T, tolerance time.Duration, v1, v2 time.Duration, format string, args ...interface{}) {
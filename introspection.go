@@ -0,0 +1,176 @@
+package kapi
+
+import (
+	"reflect"
+)
+
+// ParamSpec describes one of the values an args struct reads from the request,
+// it is a read-only copy of the information cached by TryDecodeHandlerFunction.
+type ParamSpec struct {
+	// Source is the tag the value is read from, i.e. "path",
+	// "header", "query", "cookie", "context" or "request"
+	Source string
+
+	// Key is the name used on the tag, e.g. "id" for `path:"id"`,
+	// for catch-all params it includes the trailing "*"
+	Key string
+
+	// Field is the path to the struct field, e.g. "Pagination.Page"
+	// for fields of embedded or `kapi:"inline"` structs
+	Field string
+
+	// Index is the path to the field as expected by `reflect.Value.FieldByIndex`
+	Index []int
+
+	Type reflect.Type
+
+	// Required is true if the request is rejected when the value is missing
+	Required bool
+
+	// Default is the value of the `default` tag,
+	// it is empty if the field has no default value
+	Default string
+
+	// CatchAll is true for map fields receiving all the params whose
+	// names start with the Key without the trailing "*", e.g. `header:"X-Meta-*"`
+	CatchAll bool
+
+	// DeepObject is true for query params using the bracket notation,
+	// e.g. `query:"filter,deepObject"`, when the field is a struct the
+	// params read from each of its fields are listed on Fields
+	DeepObject bool
+	Fields     []ParamSpec
+
+	// ContextKey is the key used for reading `context` values, it is either
+	// the Key itself or the typed key informed with WithContextKey
+	ContextKey any
+}
+
+// OmitsZeroValue reports whether the param should be omitted from the requests
+// when its value is the zero value of its type, which is how EncodeRequest
+// handles the optional params without changing the value read by the decoder.
+func (p ParamSpec) OmitsZeroValue() bool {
+	return omitsZeroValue(p.Required, p.Default, p.Type.Kind())
+}
+
+// BodySpec describes the field of the args struct that receives the request body
+type BodySpec struct {
+	// ContentType is either "application/json" or "application/octet-stream"
+	ContentType string
+
+	Field string
+	Index []int
+	Type  reflect.Type
+
+	Required bool
+}
+
+// Params describes every value the args struct reads from the request, grouped by
+// source in the order: path, header, query, cookie, context and request. Within each
+// source the params are sorted by key, followed by the catch-all and deepObject params.
+func (d DecodedHandlerFunction) Params() []ParamSpec {
+	var specs []ParamSpec
+	for _, source := range tagSources {
+		var params map[string]tagInfo
+		var catchAll, deepObjects []tagInfo
+		switch source.name {
+		case "path":
+			params = d.pathParams
+		case "header":
+			params, catchAll = d.headerParams, d.headerCatchAll
+		case "query":
+			params, catchAll, deepObjects = d.queryParams, d.queryCatchAll, d.queryDeepObjects
+		case "cookie":
+			params = d.cookieParams
+		case "context":
+			params = d.contextValues
+		case "request":
+			params = d.requestInfo
+		}
+
+		for _, key := range sortedKeys(params) {
+			specs = append(specs, newParamSpec(source.name, params[key]))
+		}
+		for _, info := range catchAll {
+			specs = append(specs, newParamSpec(source.name, info))
+		}
+		for _, info := range deepObjects {
+			specs = append(specs, newParamSpec(source.name, info))
+		}
+	}
+
+	return specs
+}
+
+// Body describes the body of the request, it returns nil
+// if the args struct has no field named Body.
+func (d DecodedHandlerFunction) Body() *BodySpec {
+	if d.bodyInfo == nil {
+		return nil
+	}
+
+	return &BodySpec{
+		ContentType: d.bodyContentType,
+		Field:       d.bodyInfo.Name,
+		Index:       copyIndex(d.bodyInfo.Index),
+		Type:        d.bodyInfo.Type,
+		Required:    d.bodyInfo.Required,
+	}
+}
+
+// ArgsType returns the type of the args struct received by the handler
+func (d DecodedHandlerFunction) ArgsType() reflect.Type {
+	return d.structType
+}
+
+// ResponseType returns the type of the value returned by the handler
+// besides the error, it returns nil if the handler only returns an error.
+func (d DecodedHandlerFunction) ResponseType() reflect.Type {
+	return d.responseType
+}
+
+// OperationID returns the id informed with the WithOperationID option, if any
+func (d DecodedHandlerFunction) OperationID() string {
+	return d.operationID
+}
+
+func newParamSpec(source string, info tagInfo) ParamSpec {
+	spec := ParamSpec{
+		Source:     source,
+		Key:        info.Key,
+		Field:      info.Name,
+		Index:      copyIndex(info.Index),
+		Type:       info.Type,
+		Required:   info.Required,
+		Default:    info.Default,
+		CatchAll:   info.CatchAll,
+		DeepObject: info.deepObject != nil,
+		ContextKey: info.contextKey,
+	}
+
+	if info.deepObject != nil {
+		spec.Fields = deepObjectSpecs(info.deepObject)
+	}
+
+	return spec
+}
+
+// deepObjectSpecs describes the fields of a deepObject struct, the
+// Index of each spec is relative to the struct that contains the field
+func deepObjectSpecs(info *deepObjectInfo) []ParamSpec {
+	var specs []ParamSpec
+	for _, field := range info.fields {
+		spec := newParamSpec("query", field.info)
+		if field.nested != nil {
+			spec.DeepObject = true
+			spec.Fields = deepObjectSpecs(field.nested)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// copyIndex prevents the callers from modifying the cached indexes
+func copyIndex(index []int) []int {
+	return append([]int(nil), index...)
+}
@@ -0,0 +1,134 @@
+package kapi
+
+import (
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type userKey struct{}
+
+func TestParams(t *testing.T) {
+	type filter struct {
+		Status string `query:"status,required"`
+		Limit  int    `query:"limit" default:"10"`
+	}
+	type args struct {
+		ID      uint64            `path:"id"`
+		Brand   string            `header:"brand,optional"`
+		Meta    map[string]string `header:"X-Meta-*"`
+		Page    int               `query:"page" default:"1"`
+		Sort    string            `query:"sort"`
+		Filter  filter            `query:"filter,deepObject"`
+		Session string            `cookie:"session"`
+		User    string            `context:"user"`
+		Method  string            `request:"method"`
+		Body    []byte            `content-type:"application/octet-stream"`
+	}
+
+	fnInfo, err := TryDecodeHandlerFunction(handlerType(args{}), []reflect.Type{contextType},
+		WithContextKey("user", userKey{}),
+		WithOperationID("GetUser"),
+	)
+	tt.AssertNoErr(t, err)
+
+	t.Run("should describe every param grouped by source", func(t *testing.T) {
+		stringType := reflect.TypeOf("")
+		intType := reflect.TypeOf(0)
+		tt.AssertEqual(t, fnInfo.Params(), []ParamSpec{
+			{Source: "path", Key: "id", Field: "ID", Index: []int{0}, Type: reflect.TypeOf(uint64(0)), Required: true},
+			{Source: "header", Key: "brand", Field: "Brand", Index: []int{1}, Type: stringType},
+			{Source: "header", Key: "X-Meta-*", Field: "Meta", Index: []int{2}, Type: reflect.TypeOf(map[string]string{}), CatchAll: true},
+			{Source: "query", Key: "page", Field: "Page", Index: []int{3}, Type: intType, Default: "1"},
+			{Source: "query", Key: "sort", Field: "Sort", Index: []int{4}, Type: stringType},
+			{
+				Source: "query", Key: "filter", Field: "Filter", Index: []int{5}, Type: reflect.TypeOf(filter{}),
+				DeepObject: true,
+				Fields: []ParamSpec{
+					{Source: "query", Key: "status", Field: "Filter.Status", Index: []int{0}, Type: stringType, Required: true},
+					{Source: "query", Key: "limit", Field: "Filter.Limit", Index: []int{1}, Type: intType, Default: "10"},
+				},
+			},
+			{Source: "cookie", Key: "session", Field: "Session", Index: []int{6}, Type: stringType, Required: true},
+			{Source: "context", Key: "user", Field: "User", Index: []int{7}, Type: stringType, Required: true, ContextKey: userKey{}},
+			{Source: "request", Key: "method", Field: "Method", Index: []int{8}, Type: stringType},
+		})
+	})
+
+	t.Run("should describe the body", func(t *testing.T) {
+		tt.AssertEqual(t, fnInfo.Body(), &BodySpec{
+			ContentType: "application/octet-stream",
+			Field:       "Body",
+			Index:       []int{9},
+			Type:        reflect.TypeOf([]byte{}),
+			Required:    true,
+		})
+	})
+
+	t.Run("should describe the handler", func(t *testing.T) {
+		tt.AssertEqual(t, fnInfo.ArgsType(), reflect.TypeOf(args{}))
+		tt.AssertEqual(t, fnInfo.ResponseType(), nil)
+		tt.AssertEqual(t, fnInfo.OperationID(), "GetUser")
+	})
+
+	t.Run("should not share the memory of the indexes", func(t *testing.T) {
+		fnInfo.Params()[0].Index[0] = 42
+		fnInfo.Body().Index[0] = 42
+
+		tt.AssertEqual(t, fnInfo.Params()[0].Index, []int{0})
+		tt.AssertEqual(t, fnInfo.Body().Index, []int{9})
+	})
+
+	t.Run("should return nil when there is no body", func(t *testing.T) {
+		fnInfo, err := TryDecodeHandlerFunction(handlerType(struct {
+			ID int `path:"id"`
+		}{}), []reflect.Type{contextType})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, fnInfo.Body() == nil, true)
+	})
+
+	t.Run("should default the content type of the body to JSON", func(t *testing.T) {
+		fnInfo, err := TryDecodeHandlerFunction(handlerType(struct {
+			Body struct{ Name string }
+		}{}), []reflect.Type{contextType})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, fnInfo.Body().ContentType, "application/json")
+	})
+}
+
+func TestOmitsZeroValue(t *testing.T) {
+	tests := []struct {
+		desc     string
+		spec     ParamSpec
+		expected bool
+	}{
+		{
+			desc:     "should omit optional params",
+			spec:     ParamSpec{Type: reflect.TypeOf(0)},
+			expected: true,
+		},
+		{
+			desc:     "should not omit required params",
+			spec:     ParamSpec{Type: reflect.TypeOf(""), Required: true},
+			expected: false,
+		},
+		{
+			desc:     "should not omit the zero numbers of params with defaults",
+			spec:     ParamSpec{Type: reflect.TypeOf(0), Default: "10"},
+			expected: false,
+		},
+		{
+			desc:     "should omit the empty strings of params with defaults",
+			spec:     ParamSpec{Type: reflect.TypeOf(""), Default: "name"},
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tt.AssertEqual(t, test.spec.OmitsZeroValue(), test.expected)
+		})
+	}
+}
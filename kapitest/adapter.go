@@ -0,0 +1,178 @@
+package kapitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/vingarcia/kapi"
+)
+
+// Adapter is an in-memory implementation of kapi.RequestAdapter,
+// it reads the request built with a RequestBuilder and records
+// the response so it can be inspected by the tests.
+type Adapter struct {
+	method     string
+	path       string
+	host       string
+	remoteIP   string
+	pathParams map[string]string
+	headers    http.Header
+	query      url.Values
+	body       []byte
+	ctx        context.Context
+
+	statusCode      int
+	responseHeaders http.Header
+	responseBody    []byte
+}
+
+// Must implement the kapi.RequestAdapter interface:
+var _ kapi.RequestAdapter = &Adapter{}
+
+// HTTPError is the error returned by Adapter.NewHTTPError
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+func (a *Adapter) NewHTTPError(statusCode int, msg string) error {
+	return &HTTPError{
+		StatusCode: statusCode,
+		Message:    msg,
+	}
+}
+
+func (a *Adapter) GetBody() []byte {
+	return a.body
+}
+
+func (a *Adapter) GetPathParam(paramName string) string {
+	return a.pathParams[paramName]
+}
+
+func (a *Adapter) GetHeaderParam(paramName string) string {
+	return a.headers.Get(paramName)
+}
+
+func (a *Adapter) GetQueryParam(paramName string) string {
+	return a.query.Get(paramName)
+}
+
+func (a *Adapter) GetCookie(cookieName string) string {
+	cookie, err := (&http.Request{Header: a.headers}).Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (a *Adapter) VisitHeaders(visitor func(key string, value string)) {
+	for _, key := range sortedKeys(a.headers) {
+		for _, value := range a.headers[key] {
+			visitor(key, value)
+		}
+	}
+}
+
+func (a *Adapter) VisitQueryParams(visitor func(key string, value string)) {
+	for _, key := range sortedKeys(a.query) {
+		for _, value := range a.query[key] {
+			visitor(key, value)
+		}
+	}
+}
+
+func (a *Adapter) GetMethod() string {
+	return a.method
+}
+
+func (a *Adapter) GetPath() string {
+	return a.path
+}
+
+func (a *Adapter) GetHost() string {
+	return a.host
+}
+
+func (a *Adapter) GetScheme() string {
+	return "http"
+}
+
+func (a *Adapter) GetRemoteIP() string {
+	return a.remoteIP
+}
+
+func (a *Adapter) GetRawQuery() string {
+	return a.query.Encode()
+}
+
+func (a *Adapter) GetRequestURI() string {
+	if len(a.query) == 0 {
+		return a.path
+	}
+	return a.path + "?" + a.GetRawQuery()
+}
+
+func (a *Adapter) GetContext() context.Context {
+	return a.ctx
+}
+
+func (a *Adapter) GetContextValue(contextKey any) any {
+	return a.ctx.Value(contextKey)
+}
+
+func (a *Adapter) SetContextValue(contextKey any, value any) {
+	a.ctx = context.WithValue(a.ctx, contextKey, value)
+}
+
+func (a *Adapter) SetStatus(statusCode int) {
+	a.statusCode = statusCode
+}
+
+func (a *Adapter) SetHeader(key string, value string) {
+	a.responseHeaders.Set(key, value)
+}
+
+func (a *Adapter) SetCookie(cookie kapi.Cookie) {
+	c := http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		MaxAge:   cookie.MaxAge,
+		Expires:  cookie.Expires,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HTTPOnly,
+	}
+	switch strings.ToLower(cookie.SameSite) {
+	case "lax":
+		c.SameSite = http.SameSiteLaxMode
+	case "strict":
+		c.SameSite = http.SameSiteStrictMode
+	case "none":
+		c.SameSite = http.SameSiteNoneMode
+	}
+	a.responseHeaders.Add("Set-Cookie", c.String())
+}
+
+func (a *Adapter) WriteBody(body []byte) error {
+	a.responseBody = body
+	return nil
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
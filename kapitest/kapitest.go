@@ -0,0 +1,274 @@
+// Package kapitest allows unit testing kapi handlers without
+// starting a server, by running them with an in-memory adapter:
+//
+//	resp, err := kapitest.Request().
+//	  Path("id", "42").
+//	  Header("Authorization", "Bearer fake-token").
+//	  Context("user", fakeUser).
+//	  Do(GetUser)
+//
+// Since no framework is involved the handlers must receive
+// a context.Context as their first argument, e.g.:
+//
+//	func GetUser(ctx context.Context, args GetUserArgs) (User, error)
+//
+// Handlers receiving the context of a framework can be tested with
+// the same requests using the fibertest and routingtest packages,
+// which serve them with the actual adapters in memory.
+package kapitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/vingarcia/kapi"
+)
+
+var contextType = reflect.TypeOf(new(context.Context)).Elem()
+
+// RequestBuilder builds the request sent to the handler,
+// it should be created with the Request function.
+type RequestBuilder struct {
+	adapter Adapter
+
+	// contextValues keeps the values informed with Context
+	// in order, so they can be replayed on other adapters
+	contextValues []contextValue
+
+	// err is returned by Do and Serve, it is used
+	// for reporting problems found while building the request
+	err error
+}
+
+// Request starts building a GET request to "/"
+func Request() *RequestBuilder {
+	return &RequestBuilder{
+		adapter: Adapter{
+			method:     http.MethodGet,
+			path:       "/",
+			host:       "localhost",
+			remoteIP:   "127.0.0.1",
+			pathParams: map[string]string{},
+			headers:    http.Header{},
+			query:      url.Values{},
+			ctx:        context.Background(),
+		},
+	}
+}
+
+// Method sets the method of the request
+func (b *RequestBuilder) Method(method string) *RequestBuilder {
+	b.adapter.method = method
+	return b
+}
+
+// URL sets the path of the request, it may contain a query string,
+// e.g. "/users?limit=10", whose params are added to the request.
+func (b *RequestBuilder) URL(rawURL string) *RequestBuilder {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		b.err = fmt.Errorf("kapitest: invalid url '%s': %w", rawURL, err)
+		return b
+	}
+
+	b.adapter.path = u.Path
+	for key, values := range u.Query() {
+		b.adapter.query[key] = append(b.adapter.query[key], values...)
+	}
+	return b
+}
+
+// Host sets the host of the request, it defaults to "localhost"
+func (b *RequestBuilder) Host(host string) *RequestBuilder {
+	b.adapter.host = host
+	return b
+}
+
+// RemoteIP sets the IP of the client, it defaults to "127.0.0.1"
+func (b *RequestBuilder) RemoteIP(ip string) *RequestBuilder {
+	b.adapter.remoteIP = ip
+	return b
+}
+
+// Path sets a param of the route, i.e. a value read with the `path` tag
+func (b *RequestBuilder) Path(key string, value string) *RequestBuilder {
+	b.adapter.pathParams[key] = value
+	return b
+}
+
+// Header adds a header to the request
+func (b *RequestBuilder) Header(key string, value string) *RequestBuilder {
+	b.adapter.headers.Add(key, value)
+	return b
+}
+
+// Query adds a query param to the request
+func (b *RequestBuilder) Query(key string, value string) *RequestBuilder {
+	b.adapter.query.Add(key, value)
+	return b
+}
+
+// Cookie adds a cookie to the `Cookie` header of the request
+func (b *RequestBuilder) Cookie(name string, value string) *RequestBuilder {
+	cookie := (&http.Cookie{Name: name, Value: value}).String()
+	if current := b.adapter.headers.Get("Cookie"); current != "" {
+		cookie = current + "; " + cookie
+	}
+	b.adapter.headers.Set("Cookie", cookie)
+	return b
+}
+
+// Body sets the raw body of the request
+func (b *RequestBuilder) Body(body []byte) *RequestBuilder {
+	b.adapter.body = body
+	return b
+}
+
+// JSON encodes the value as the body of the request
+// and sets the `Content-Type` header to application/json.
+func (b *RequestBuilder) JSON(body interface{}) *RequestBuilder {
+	rawJSON, err := json.Marshal(body)
+	if err != nil {
+		b.err = fmt.Errorf("kapitest: could not marshal the request body: %w", err)
+		return b
+	}
+
+	b.adapter.body = rawJSON
+	b.adapter.headers.Set("Content-Type", "application/json")
+	return b
+}
+
+// Context stores a value on the request context, i.e. a value read with the
+// `context` tag, the key may be a string or a typed key (see kapi.WithContextKey).
+func (b *RequestBuilder) Context(key any, value any) *RequestBuilder {
+	b.adapter.SetContextValue(key, value)
+	b.contextValues = append(b.contextValues, contextValue{key: key, value: value})
+	return b
+}
+
+type contextValue struct {
+	key   any
+	value any
+}
+
+// HTTPRequest returns the request as a *http.Request, so it can be sent
+// through the router of a framework, e.g. by the `fibertest` package.
+//
+// The params informed with Path and Context are not part of the request,
+// they can be read with PathParams and VisitContextValues.
+func (b *RequestBuilder) HTTPRequest() (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	req, err := http.NewRequest(b.adapter.method, "http://"+b.adapter.host+b.adapter.GetRequestURI(), bytes.NewReader(b.adapter.body))
+	if err != nil {
+		return nil, fmt.Errorf("kapitest: could not build the request: %w", err)
+	}
+
+	req.Header = b.adapter.headers.Clone()
+	req.RemoteAddr = net.JoinHostPort(b.adapter.remoteIP, "0")
+	return req, nil
+}
+
+// PathParams returns a copy of the params informed with Path
+func (b *RequestBuilder) PathParams() map[string]string {
+	params := make(map[string]string, len(b.adapter.pathParams))
+	for key, value := range b.adapter.pathParams {
+		params[key] = value
+	}
+	return params
+}
+
+// VisitContextValues calls the visitor once for each value
+// informed with Context, in the order they were informed.
+func (b *RequestBuilder) VisitContextValues(visitor func(key any, value any)) {
+	for _, v := range b.contextValues {
+		visitor(v.key, v.value)
+	}
+}
+
+// Response is the response written by the handler
+type Response struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+
+	// Err is the error returned by the handler, if any, in which case
+	// the status and the body are set just like a framework would do,
+	// i.e. using the status and message of the errors created with
+	// NewHTTPError and using status 500 for any other error.
+	Err error
+}
+
+// DecodeJSON decodes the body of the response into the target
+func (r Response) DecodeJSON(target interface{}) error {
+	return json.Unmarshal(r.Body, target)
+}
+
+// Do decodes the request into the args of the handler, calls it, and returns
+// the response it wrote. The handler must have one of the following signatures:
+//
+//	func(ctx context.Context, args MyArgs) error
+//	func(ctx context.Context, args MyArgs) (MyResponse, error)
+//
+// The error is only returned if the handler is invalid
+// or if there was a problem building the request.
+func (b *RequestBuilder) Do(fn interface{}, opts ...kapi.Option) (Response, error) {
+	fnInfo, err := kapi.TryDecodeHandlerFunction(reflect.TypeOf(fn), []reflect.Type{contextType}, opts...)
+	if err != nil {
+		return Response{}, err
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	return b.Serve(func(request kapi.RequestAdapter) error {
+		inputStructPtr, err := kapi.UnmarshalRequestAsStruct(request, fnInfo)
+		if err != nil {
+			return err
+		}
+
+		outputs := fnValue.Call([]reflect.Value{reflect.ValueOf(request.GetContext()), inputStructPtr.Elem()})
+		return kapi.WriteHandlerResponse(request, fnInfo, outputs)
+	})
+}
+
+// Serve calls the handler with the in-memory adapter and returns the response
+// it wrote, it is useful for testing code that uses the kapi.RequestAdapter directly.
+func (b *RequestBuilder) Serve(handler func(request kapi.RequestAdapter) error) (Response, error) {
+	if b.err != nil {
+		return Response{}, b.err
+	}
+
+	// A copy is used so the builder can be reused:
+	adapter := b.adapter
+	adapter.statusCode = http.StatusOK
+	adapter.responseHeaders = http.Header{}
+	adapter.responseBody = nil
+
+	err := handler(&adapter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := err.Error()
+		if httpErr, ok := err.(*HTTPError); ok {
+			status = httpErr.StatusCode
+			message = httpErr.Message
+		}
+
+		adapter.statusCode = status
+		adapter.responseHeaders.Set("Content-Type", "text/plain; charset=utf-8")
+		adapter.responseBody = []byte(message)
+	}
+
+	return Response{
+		StatusCode: adapter.statusCode,
+		Headers:    adapter.responseHeaders,
+		Body:       adapter.responseBody,
+		Err:        err,
+	}, nil
+}
@@ -0,0 +1,222 @@
+package kapitest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/vingarcia/kapi"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	t.Run("should merge the query of the URL with the query params", func(t *testing.T) {
+		var path string
+		var query []string
+		_, err := Request().
+			Query("a", "1").
+			URL("/users?a=2&b=3").
+			Query("b", "4").
+			Serve(func(request kapi.RequestAdapter) error {
+				path = request.GetPath()
+				request.VisitQueryParams(func(key string, value string) {
+					query = append(query, key+"="+value)
+				})
+				return nil
+			})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, path, "/users")
+		tt.AssertEqual(t, query, []string{"a=1", "a=2", "b=3", "b=4"})
+	})
+
+	t.Run("should report invalid URLs", func(t *testing.T) {
+		_, err := Request().URL("%zz").Serve(func(request kapi.RequestAdapter) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "kapitest: invalid url '%zz'")
+	})
+
+	t.Run("should append the cookies to the Cookie header", func(t *testing.T) {
+		var header, session, theme string
+		_, err := Request().
+			Cookie("session", "fake-session").
+			Cookie("theme", "dark").
+			Serve(func(request kapi.RequestAdapter) error {
+				header = request.GetHeaderParam("Cookie")
+				session = request.GetCookie("session")
+				theme = request.GetCookie("theme")
+				return nil
+			})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, header, "session=fake-session; theme=dark")
+		tt.AssertEqual(t, session, "fake-session")
+		tt.AssertEqual(t, theme, "dark")
+	})
+
+	t.Run("should encode the JSON body and set its content type", func(t *testing.T) {
+		var body []byte
+		var contentType string
+		_, err := Request().
+			JSON(map[string]int{"id": 42}).
+			Serve(func(request kapi.RequestAdapter) error {
+				body = request.GetBody()
+				contentType = request.GetHeaderParam("Content-Type")
+				return nil
+			})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, string(body), `{"id":42}`)
+		tt.AssertEqual(t, contentType, "application/json")
+	})
+
+	t.Run("should report bodies that can't be encoded", func(t *testing.T) {
+		_, err := Request().JSON(make(chan int)).Serve(func(request kapi.RequestAdapter) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "kapitest: could not marshal the request body")
+	})
+
+	t.Run("should build an equivalent *http.Request", func(t *testing.T) {
+		req, err := Request().
+			Method("PUT").
+			Host("example.com").
+			URL("/users/42?a=1").
+			Header("Authorization", "Bearer fake").
+			Cookie("session", "fake-session").
+			Path("id", "42").
+			Context("user", "fake-user").
+			Body([]byte("fake body")).
+			HTTPRequest()
+		tt.AssertNoErr(t, err)
+
+		body, err := io.ReadAll(req.Body)
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, req.Method, "PUT")
+		tt.AssertEqual(t, req.URL.String(), "http://example.com/users/42?a=1")
+		tt.AssertEqual(t, req.Header.Get("Authorization"), "Bearer fake")
+		tt.AssertEqual(t, req.Header.Get("Cookie"), "session=fake-session")
+		tt.AssertEqual(t, string(body), "fake body")
+	})
+
+	t.Run("should keep the path params and context values", func(t *testing.T) {
+		b := Request().
+			Path("id", "42").
+			Context("user", "fake-user").
+			Context(struct{}{}, "fake-typed-value")
+
+		var values []interface{}
+		b.VisitContextValues(func(key any, value any) {
+			values = append(values, key, value)
+		})
+
+		tt.AssertEqual(t, b.PathParams(), map[string]string{"id": "42"})
+		tt.AssertEqual(t, values, []interface{}{"user", "fake-user", struct{}{}, "fake-typed-value"})
+	})
+}
+
+func TestServe(t *testing.T) {
+	t.Run("should return the response written by the handler", func(t *testing.T) {
+		resp, err := Request().Serve(func(request kapi.RequestAdapter) error {
+			request.SetStatus(http.StatusCreated)
+			request.SetHeader("Location", "/users/42")
+			request.SetCookie(kapi.Cookie{Name: "session", Value: "fake-session", SameSite: "Lax"})
+			return request.WriteBody([]byte("fake body"))
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusCreated)
+		tt.AssertEqual(t, resp.Headers.Get("Location"), "/users/42")
+		tt.AssertEqual(t, resp.Headers.Get("Set-Cookie"), "session=fake-session; SameSite=Lax")
+		tt.AssertEqual(t, string(resp.Body), "fake body")
+		tt.AssertEqual(t, resp.Err, nil)
+	})
+
+	t.Run("should use the status and message of HTTP errors", func(t *testing.T) {
+		resp, err := Request().Serve(func(request kapi.RequestAdapter) error {
+			request.SetStatus(http.StatusCreated)
+			return request.NewHTTPError(http.StatusTeapot, "fake message")
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusTeapot)
+		tt.AssertEqual(t, string(resp.Body), "fake message")
+		tt.AssertEqual(t, resp.Headers.Get("Content-Type"), "text/plain; charset=utf-8")
+		tt.AssertEqual(t, resp.Err, error(&HTTPError{StatusCode: http.StatusTeapot, Message: "fake message"}))
+	})
+
+	t.Run("should use status 500 for other errors", func(t *testing.T) {
+		fakeErr := errors.New("fake error")
+		resp, err := Request().Serve(func(request kapi.RequestAdapter) error {
+			return fakeErr
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusInternalServerError)
+		tt.AssertEqual(t, string(resp.Body), "fake error")
+		tt.AssertEqual(t, resp.Err, fakeErr)
+	})
+
+	t.Run("should allow reusing the builder", func(t *testing.T) {
+		b := Request()
+		_, err := b.Serve(func(request kapi.RequestAdapter) error {
+			request.SetHeader("X-Fake", "fake")
+			return request.NewHTTPError(http.StatusTeapot, "fake message")
+		})
+		tt.AssertNoErr(t, err)
+
+		resp, err := b.Serve(func(request kapi.RequestAdapter) error {
+			return nil
+		})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertEqual(t, resp.Headers, http.Header{})
+		tt.AssertEqual(t, len(resp.Body), 0)
+	})
+}
+
+func TestDo(t *testing.T) {
+	type args struct {
+		ID   int    `path:"id"`
+		User string `context:"user"`
+	}
+
+	t.Run("should decode the args and write the response", func(t *testing.T) {
+		resp, err := Request().
+			Path("id", "42").
+			Context("user", "fake-user").
+			Do(func(ctx context.Context, args args) (args, error) {
+				return args, nil
+			})
+		tt.AssertNoErr(t, err)
+
+		var body map[string]interface{}
+		tt.AssertNoErr(t, resp.DecodeJSON(&body))
+		tt.AssertEqual(t, resp.StatusCode, http.StatusOK)
+		tt.AssertEqual(t, body, map[string]interface{}{"ID": float64(42), "User": "fake-user"})
+	})
+
+	t.Run("should return the decoding errors on the response", func(t *testing.T) {
+		resp, err := Request().
+			Context("user", "fake-user").
+			Do(func(ctx context.Context, args args) error {
+				return nil
+			})
+		tt.AssertNoErr(t, err)
+
+		tt.AssertEqual(t, resp.StatusCode, http.StatusBadRequest)
+		tt.AssertEqual(t, string(resp.Body), "path param 'id' is empty")
+	})
+
+	t.Run("should report invalid handlers", func(t *testing.T) {
+		_, err := Request().Do(func(ctx context.Context, args int) error {
+			return nil
+		})
+		tt.AssertErrContains(t, err, "the last argument must be a struct!")
+	})
+}
@@ -0,0 +1,498 @@
+package kapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIDocument is an OpenAPI 3.1 document, it can be
+// encoded as JSON with the `encoding/json` package.
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// OpenAPIInfo describes the API on the OpenAPI document,
+// the Title and the Version are required by the specification.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Style    string      `json:"style,omitempty"`
+	Explode  bool        `json:"explode,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIHeader struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas,omitempty"`
+}
+
+// JSONSchema is the subset of JSON Schema used for describing
+// the params, the bodies and the responses of the handlers.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Default              json.RawMessage        `json:"default,omitempty"`
+}
+
+// OpenAPI builds an OpenAPI 3.1 document describing the routes of the registry,
+// the params are described from the `path`, `header`, `query` and `cookie` tags
+// of the args structs, the request bodies from the type of their `Body` fields
+// and the responses from the types returned by the handlers, e.g.:
+//
+//	doc, _ := json.Marshal(registry.OpenAPI(kapi.OpenAPIInfo{
+//	  Title:   "Users API",
+//	  Version: "1.0.0",
+//	}))
+//
+// Named struct types are described once on the components of the document,
+// following the same rules used by `encoding/json` for naming their fields.
+func (r *Registry) OpenAPI(info OpenAPIInfo) OpenAPIDocument {
+	b := schemaBuilder{
+		schemas: map[string]*JSONSchema{},
+		names:   map[reflect.Type]string{},
+	}
+
+	doc := OpenAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+	for _, route := range r.Routes() {
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = b.operation(route.Path, route.Handler)
+	}
+
+	if len(b.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{
+			Schemas: b.schemas,
+		}
+	}
+
+	return doc
+}
+
+// schemaBuilder builds the schemas of an OpenAPI document,
+// collecting the named structs as components.
+type schemaBuilder struct {
+	schemas map[string]*JSONSchema
+	names   map[reflect.Type]string
+}
+
+func (b *schemaBuilder) operation(routePath string, funcInfo DecodedHandlerFunction) *OpenAPIOperation {
+	op := OpenAPIOperation{
+		OperationID: funcInfo.operationID,
+		Responses:   map[string]OpenAPIResponse{},
+	}
+
+	// Every param of the path must be described, including the ones the
+	// handler doesn't read, e.g. the params of the prefix of a group:
+	for _, match := range openAPIPathParamRegex.FindAllStringSubmatch(routePath, -1) {
+		key := match[1]
+		if _, found := funcInfo.pathParams[key]; found {
+			continue
+		}
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name:     key,
+			In:       "path",
+			Required: true,
+			Schema:   &JSONSchema{Type: "string"},
+		})
+	}
+
+	for _, source := range []struct {
+		in     string
+		params map[string]tagInfo
+	}{
+		{in: "path", params: funcInfo.pathParams},
+		{in: "query", params: funcInfo.queryParams},
+		{in: "header", params: funcInfo.headerParams},
+		{in: "cookie", params: funcInfo.cookieParams},
+	} {
+		for _, key := range sortedKeys(source.params) {
+			info := source.params[key]
+			schema := kindSchema(info.Kind)
+			schema.Default = defaultValue(info)
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     key,
+				In:       source.in,
+				Required: info.Required,
+				Schema:   schema,
+			})
+		}
+	}
+
+	// Only the query catch-all params can be described on OpenAPI,
+	// as a free form object with the style used for regular params:
+	for _, info := range funcInfo.queryCatchAll {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name:    info.Key,
+			In:      "query",
+			Style:   "form",
+			Explode: true,
+			Schema: &JSONSchema{
+				Type:                 "object",
+				AdditionalProperties: &JSONSchema{Type: "string"},
+			},
+		})
+	}
+
+	for _, info := range funcInfo.queryDeepObjects {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name:     info.Key,
+			In:       "query",
+			Required: info.Required,
+			Style:    "deepObject",
+			Explode:  true,
+			Schema:   deepObjectSchema(info.deepObject),
+		})
+	}
+
+	if funcInfo.bodyInfo != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]OpenAPIMediaType{
+				funcInfo.bodyContentType: {Schema: b.bodySchema(funcInfo.bodyInfo.Type)},
+			},
+		}
+	}
+
+	status, response := b.response(funcInfo)
+	op.Responses[status] = response
+
+	return &op
+}
+
+func (b *schemaBuilder) response(funcInfo DecodedHandlerFunction) (status string, _ OpenAPIResponse) {
+	if funcInfo.responseType == nil {
+		return "200", OpenAPIResponse{
+			Description: http.StatusText(http.StatusOK),
+		}
+	}
+
+	info := funcInfo.responseInfo
+	if info == nil {
+		contentType := "application/json"
+		if funcInfo.responseType == byteArrType {
+			contentType = "application/octet-stream"
+		}
+		return "200", OpenAPIResponse{
+			Description: http.StatusText(http.StatusOK),
+			Content: map[string]OpenAPIMediaType{
+				contentType: {Schema: b.bodySchema(funcInfo.responseType)},
+			},
+		}
+	}
+
+	// The status is only known at runtime when
+	// the response struct has a status field:
+	status = "200"
+	response := OpenAPIResponse{
+		Description: http.StatusText(http.StatusOK),
+	}
+	if info.statusIdx >= 0 {
+		status = "default"
+		response.Description = "Response"
+	}
+
+	for _, header := range info.headers {
+		if response.Headers == nil {
+			response.Headers = map[string]OpenAPIHeader{}
+		}
+		response.Headers[header.Key] = OpenAPIHeader{
+			Schema: kindSchema(header.Kind),
+		}
+	}
+
+	if info.body != nil {
+		contentType := info.bodyContentType
+		if contentType == "" {
+			contentType = "application/json"
+			if info.body.Type == byteArrType {
+				contentType = "application/octet-stream"
+			}
+		}
+		response.Content = map[string]OpenAPIMediaType{
+			contentType: {Schema: b.bodySchema(info.body.Type)},
+		}
+	}
+
+	return status, response
+}
+
+// bodySchema describes a body, which is either raw bytes or JSON
+func (b *schemaBuilder) bodySchema(t reflect.Type) *JSONSchema {
+	if t == byteArrType {
+		return &JSONSchema{Type: "string", Format: "binary"}
+	}
+	return b.schema(t)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf(new(json.Marshaler)).Elem()
+	textMarshalerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
+)
+
+// schema describes how the type is encoded by `encoding/json`
+func (b *schemaBuilder) schema(t reflect.Type) *JSONSchema {
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &JSONSchema{}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// The encoding is unknown so any value is accepted:
+		return &JSONSchema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &JSONSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schema(t.Elem())
+	case reflect.Interface:
+		return &JSONSchema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return b.structRef(t)
+	}
+
+	return kindSchema(t.Kind())
+}
+
+// structRef describes the named struct on the components of the document
+func (b *schemaBuilder) structRef(t reflect.Type) *JSONSchema {
+	name, found := b.names[t]
+	if !found {
+		name = b.componentName(t)
+		b.names[t] = name
+
+		// The name is reserved before building the
+		// schema so recursive types reference it:
+		b.schemas[name] = nil
+		b.schemas[name] = b.structSchema(t)
+	}
+
+	return &JSONSchema{Ref: "#/components/schemas/" + name}
+}
+
+// openAPIPathParamRegex matches the params of the paths
+// of the registry, which use the OpenAPI syntax, e.g. `{id}`
+var openAPIPathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+var invalidComponentChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// componentName returns the name of the type qualified by its
+// package only if another type with the same name was used before
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := invalidComponentChars.ReplaceAllString(t.Name(), "_")
+	if _, found := b.schemas[name]; !found {
+		return name
+	}
+
+	name = path.Base(t.PkgPath()) + "." + name
+	qualifiedName := name
+	for i := 2; ; i++ {
+		if _, found := b.schemas[qualifiedName]; !found {
+			return qualifiedName
+		}
+		qualifiedName = name + strconv.Itoa(i)
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *JSONSchema {
+	schema := JSONSchema{
+		Type:       "object",
+		Properties: map[string]*JSONSchema{},
+	}
+
+	depths := map[string]int{}
+	b.collectProperties(&schema, depths, t, 0)
+
+	return &schema
+}
+
+// collectProperties adds the fields of the struct to the schema, including the
+// fields promoted from embedded structs, in which case the shallower fields win
+func (b *schemaBuilder) collectProperties(schema *JSONSchema, depths map[string]int, t reflect.Type, depth int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.collectProperties(schema, depths, fieldType, depth+1)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if d, found := depths[name]; found && d <= depth {
+			continue
+		}
+		depths[name] = depth
+
+		fieldSchema := b.schema(field.Type)
+		if contains(opts[1:], "string") && isStringOptionKind(fieldType.Kind()) {
+			fieldSchema = &JSONSchema{Type: "string"}
+		}
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+// isStringOptionKind reports whether the `json:",string"`
+// option is applied by `encoding/json` on fields of this kind
+func isStringOptionKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// kindSchema describes the values of the basic kinds
+func kindSchema(kind reflect.Kind) *JSONSchema {
+	zero := 0
+	switch kind {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &JSONSchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &JSONSchema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint64:
+		return &JSONSchema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &JSONSchema{Type: "integer", Format: "int32", Minimum: &zero}
+	case reflect.Float32:
+		return &JSONSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &JSONSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	}
+
+	return &JSONSchema{}
+}
+
+// defaultValue returns the default value of the param encoded with
+// the type used for it on the JSON Schema, or nil if it has no default
+func defaultValue(info tagInfo) json.RawMessage {
+	if !info.hasDefault {
+		return nil
+	}
+
+	v, err := decodeType(info.Type, info.Default)
+	if err != nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+// deepObjectSchema describes the object decoded from the bracket notation
+func deepObjectSchema(info *deepObjectInfo) *JSONSchema {
+	if info.isMap {
+		return &JSONSchema{
+			Type:                 "object",
+			AdditionalProperties: &JSONSchema{Type: "string"},
+		}
+	}
+
+	schema := JSONSchema{
+		Type:       "object",
+		Properties: map[string]*JSONSchema{},
+	}
+	for _, field := range info.fields {
+		if field.nested != nil {
+			schema.Properties[field.info.Key] = deepObjectSchema(field.nested)
+			continue
+		}
+
+		fieldSchema := kindSchema(field.info.Kind)
+		fieldSchema.Default = defaultValue(field.info)
+		schema.Properties[field.info.Key] = fieldSchema
+	}
+
+	return &schema
+}
@@ -0,0 +1,235 @@
+package kapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vingarcia/kapi"
+	adapter "github.com/vingarcia/kapi/adapters/fiberV2"
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+type openAPIUser struct {
+	ID      int            `json:"id"`
+	Name    string         `json:"name,omitempty"`
+	Friends []openAPIUser  `json:"friends"`
+	Address openAPIAddress `json:"address"`
+	secret  string
+}
+
+type openAPIAddress struct {
+	City string `json:"city"`
+}
+
+type openAPIFilter struct {
+	Status string `query:"status"`
+	Limit  int    `query:"limit" default:"10"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Run("should describe the params of the args struct", func(t *testing.T) {
+		registry := kapi.NewRegistry()
+		adapter.Get(fiber.New(), "/users/:id", func(ctx context.Context, args struct {
+			ID      uint64            `path:"id"`
+			Token   string            `header:"Authorization"`
+			Page    int               `query:"page" default:"1"`
+			Offset  uint              `query:"offset" default:"0"`
+			Sort    string            `query:"sort" default:""`
+			Size    uint8             `query:"size"`
+			Session string            `cookie:"session,optional"`
+			Filter  openAPIFilter     `query:"filter,deepObject"`
+			Extra   map[string]string `query:"x_*"`
+			User    string            `context:"user,optional"`
+			Method  string            `request:"method"`
+		}) error {
+			return nil
+		}, kapi.WithRegistry(registry), kapi.WithOperationID("GetUser"))
+
+		doc := registry.OpenAPI(kapi.OpenAPIInfo{Title: "Users API", Version: "1.0.0"})
+		op := doc.Paths["/users/{id}"]["get"]
+		tt.AssertEqual(t, op.OperationID, "GetUser")
+
+		zero := 0
+		tt.AssertEqual(t, op.Parameters, []kapi.OpenAPIParameter{
+			{Name: "id", In: "path", Required: true, Schema: &kapi.JSONSchema{Type: "integer", Format: "int64", Minimum: &zero}},
+			{Name: "offset", In: "query", Schema: &kapi.JSONSchema{Type: "integer", Format: "int64", Minimum: &zero, Default: json.RawMessage(`0`)}},
+			{Name: "page", In: "query", Schema: &kapi.JSONSchema{Type: "integer", Format: "int64", Default: json.RawMessage(`1`)}},
+			{Name: "size", In: "query", Schema: &kapi.JSONSchema{Type: "integer", Format: "int32", Minimum: &zero}},
+			{Name: "sort", In: "query", Schema: &kapi.JSONSchema{Type: "string", Default: json.RawMessage(`""`)}},
+			{Name: "Authorization", In: "header", Required: true, Schema: &kapi.JSONSchema{Type: "string"}},
+			{Name: "session", In: "cookie", Schema: &kapi.JSONSchema{Type: "string"}},
+			{Name: "x_*", In: "query", Style: "form", Explode: true, Schema: &kapi.JSONSchema{
+				Type:                 "object",
+				AdditionalProperties: &kapi.JSONSchema{Type: "string"},
+			}},
+			{Name: "filter", In: "query", Style: "deepObject", Explode: true, Schema: &kapi.JSONSchema{
+				Type: "object",
+				Properties: map[string]*kapi.JSONSchema{
+					"status": {Type: "string"},
+					"limit":  {Type: "integer", Format: "int64", Default: json.RawMessage(`10`)},
+				},
+			}},
+		})
+	})
+
+	t.Run("should describe the params of the prefix of the groups", func(t *testing.T) {
+		registry := kapi.NewRegistry()
+		api := fiber.New().Group("/api/:org")
+		adapter.Get(api, "/users/:id", func(ctx context.Context, args struct {
+			ID int `path:"id"`
+		}) error {
+			return nil
+		}, kapi.WithRegistry(registry))
+		adapter.Get(api, "/teams/:team", func(ctx context.Context, args struct {
+			Org  string `path:"org"`
+			Team string `path:"team"`
+		}) error {
+			return nil
+		}, kapi.WithRegistry(registry))
+
+		doc := registry.OpenAPI(kapi.OpenAPIInfo{Title: "Users API", Version: "1.0.0"})
+
+		tt.AssertEqual(t, doc.Paths["/api/{org}/users/{id}"]["get"].Parameters, []kapi.OpenAPIParameter{
+			{Name: "org", In: "path", Required: true, Schema: &kapi.JSONSchema{Type: "string"}},
+			{Name: "id", In: "path", Required: true, Schema: &kapi.JSONSchema{Type: "integer", Format: "int64"}},
+		})
+		tt.AssertEqual(t, doc.Paths["/api/{org}/teams/{team}"]["get"].Parameters, []kapi.OpenAPIParameter{
+			{Name: "org", In: "path", Required: true, Schema: &kapi.JSONSchema{Type: "string"}},
+			{Name: "team", In: "path", Required: true, Schema: &kapi.JSONSchema{Type: "string"}},
+		})
+	})
+
+	t.Run("should describe the bodies reusing the components", func(t *testing.T) {
+		registry := kapi.NewRegistry()
+		app := fiber.New()
+		adapter.Post(app, "/users", func(ctx context.Context, args struct {
+			Body openAPIUser
+		}) (openAPIUser, error) {
+			return args.Body, nil
+		}, kapi.WithRegistry(registry))
+		adapter.Put(app, "/avatars/:id", func(ctx context.Context, args struct {
+			ID   int    `path:"id"`
+			Body []byte `content-type:"application/octet-stream"`
+		}) ([]byte, error) {
+			return args.Body, nil
+		}, kapi.WithRegistry(registry))
+
+		doc := registry.OpenAPI(kapi.OpenAPIInfo{Title: "Users API", Version: "1.0.0"})
+
+		userRef := &kapi.JSONSchema{Ref: "#/components/schemas/openAPIUser"}
+		op := doc.Paths["/users"]["post"]
+		tt.AssertEqual(t, op.RequestBody, &kapi.OpenAPIRequestBody{
+			Required: true,
+			Content:  map[string]kapi.OpenAPIMediaType{"application/json": {Schema: userRef}},
+		})
+		tt.AssertEqual(t, op.Responses, map[string]kapi.OpenAPIResponse{
+			"200": {
+				Description: "OK",
+				Content:     map[string]kapi.OpenAPIMediaType{"application/json": {Schema: userRef}},
+			},
+		})
+
+		binary := &kapi.JSONSchema{Type: "string", Format: "binary"}
+		op = doc.Paths["/avatars/{id}"]["put"]
+		tt.AssertEqual(t, op.RequestBody.Content, map[string]kapi.OpenAPIMediaType{
+			"application/octet-stream": {Schema: binary},
+		})
+		tt.AssertEqual(t, op.Responses["200"].Content, map[string]kapi.OpenAPIMediaType{
+			"application/octet-stream": {Schema: binary},
+		})
+
+		tt.AssertEqual(t, doc.Components, &kapi.OpenAPIComponents{
+			Schemas: map[string]*kapi.JSONSchema{
+				"openAPIUser": {
+					Type: "object",
+					Properties: map[string]*kapi.JSONSchema{
+						"id":      {Type: "integer", Format: "int64"},
+						"name":    {Type: "string"},
+						"friends": {Type: "array", Items: userRef},
+						"address": {Ref: "#/components/schemas/openAPIAddress"},
+					},
+				},
+				"openAPIAddress": {
+					Type: "object",
+					Properties: map[string]*kapi.JSONSchema{
+						"city": {Type: "string"},
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("should describe the fields of response structs", func(t *testing.T) {
+		type createUserResponse struct {
+			Status   int         `status:""`
+			Location string      `header:"Location"`
+			Session  kapi.Cookie `cookie:"sid"`
+			Body     openAPIUser `content-type:"application/json"`
+		}
+		type headersResponse struct {
+			Count int `header:"X-Count"`
+		}
+		type comment struct {
+			ID   int    `json:"id"`
+			Body string `json:"body"`
+		}
+
+		registry := kapi.NewRegistry()
+		app := fiber.New()
+		adapter.Post(app, "/users", func(ctx context.Context, args struct{}) (createUserResponse, error) {
+			return createUserResponse{}, nil
+		}, kapi.WithRegistry(registry))
+		adapter.Get(app, "/users/count", func(ctx context.Context, args struct{}) (headersResponse, error) {
+			return headersResponse{}, nil
+		}, kapi.WithRegistry(registry))
+		adapter.Get(app, "/comments", func(ctx context.Context, args struct{}) (comment, error) {
+			return comment{}, nil
+		}, kapi.WithRegistry(registry))
+
+		doc := registry.OpenAPI(kapi.OpenAPIInfo{Title: "Users API", Version: "1.0.0"})
+
+		tt.AssertEqual(t, doc.Paths["/users"]["post"].Responses, map[string]kapi.OpenAPIResponse{
+			// The status is only known at runtime:
+			"default": {
+				Description: "Response",
+				Headers: map[string]kapi.OpenAPIHeader{
+					"Location": {Schema: &kapi.JSONSchema{Type: "string"}},
+				},
+				Content: map[string]kapi.OpenAPIMediaType{
+					"application/json": {Schema: &kapi.JSONSchema{Ref: "#/components/schemas/openAPIUser"}},
+				},
+			},
+		})
+		tt.AssertEqual(t, doc.Paths["/users/count"]["get"].Responses, map[string]kapi.OpenAPIResponse{
+			"200": {
+				Description: "OK",
+				Headers: map[string]kapi.OpenAPIHeader{
+					"X-Count": {Schema: &kapi.JSONSchema{Type: "integer", Format: "int64"}},
+				},
+			},
+		})
+		tt.AssertEqual(t, doc.Paths["/comments"]["get"].Responses, map[string]kapi.OpenAPIResponse{
+			"200": {
+				Description: "OK",
+				Content: map[string]kapi.OpenAPIMediaType{
+					"application/json": {Schema: &kapi.JSONSchema{Ref: "#/components/schemas/comment"}},
+				},
+			},
+		})
+	})
+
+	t.Run("should encode the zero defaults", func(t *testing.T) {
+		registry := kapi.NewRegistry()
+		adapter.Get(fiber.New(), "/users", func(ctx context.Context, args struct {
+			Offset int `query:"offset" default:"0"`
+		}) error {
+			return nil
+		}, kapi.WithRegistry(registry))
+
+		encoded, err := json.Marshal(registry.OpenAPI(kapi.OpenAPIInfo{Title: "Users API", Version: "1.0.0"}))
+		tt.AssertNoErr(t, err)
+		tt.AssertContains(t, string(encoded), `"schema":{"type":"integer","format":"int64","default":0}`)
+	})
+}
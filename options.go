@@ -0,0 +1,124 @@
+package kapi
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+)
+
+// Option customizes how a handler is adapted, options are
+// passed as the last arguments of the `Adapt` functions, e.g.:
+//
+//	fiber.Adapt(myHandler, kapi.WithErrorHandler(myErrorHandler))
+type Option func(*config)
+
+type config struct {
+	errorHandler   ErrorHandler
+	trustedProxies []*net.IPNet
+	contextKeys    map[string]any
+	registry       *Registry
+	operationID    string
+
+	// problems are reported by TryDecodeHandlerFunction
+	// since options have no way of returning errors
+	problems []string
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		errorHandler: DefaultErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithErrorHandler replaces the DefaultErrorHandler allowing the user
+// to localize the error messages, change status codes or hide internal
+// details of the errors produced while decoding the request.
+func WithErrorHandler(fn ErrorHandler) Option {
+	return func(c *config) {
+		if fn != nil {
+			c.errorHandler = fn
+		}
+	}
+}
+
+// WithTrustedProxies informs the IPs or CIDR ranges, e.g. "10.0.0.0/8",
+// of the proxies whose `X-Forwarded-For` header can be trusted when
+// filling the fields tagged with `request:"remote_ip"`.
+//
+// If no trusted proxies are informed the IP of the
+// direct peer of the connection is always used.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(c *config) {
+		for _, proxy := range proxies {
+			cidr := proxy
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				c.problems = append(c.problems, fmt.Sprintf("invalid trusted proxy '%s': %s", proxy, err.Error()))
+				continue
+			}
+			c.trustedProxies = append(c.trustedProxies, ipNet)
+		}
+	}
+}
+
+// WithContextKey maps the name used on a `context` tag to a typed context key,
+// allowing handlers to read values stored with keys that are not strings, e.g.:
+//
+//	type userKey struct{}
+//
+//	fiber.Adapt(func(ctx *fiber.Ctx, args struct {
+//	  User User `context:"user"`
+//	}) error {
+//	  // ...
+//	}, kapi.WithContextKey("user", userKey{}))
+//
+// The value is then read with `RequestAdapter.GetContextValue(userKey{})`.
+func WithContextKey(name string, key any) Option {
+	return func(c *config) {
+		if key == nil {
+			c.problems = append(c.problems, fmt.Sprintf("the context key informed for '%s' must not be nil", name))
+			return
+		}
+		if !reflect.TypeOf(key).Comparable() {
+			c.problems = append(c.problems, fmt.Sprintf("the context key informed for '%s' must be comparable", name))
+			return
+		}
+
+		if c.contextKeys == nil {
+			c.contextKeys = map[string]any{}
+		}
+		c.contextKeys[name] = key
+	}
+}
+
+// WithRegistry adds the route to the registry when the handler is registered
+// with the route helpers of the adapters, e.g. `fiber.Get()`, so it can be
+// described with `Registry.OpenAPI()`.
+func WithRegistry(registry *Registry) Option {
+	return func(c *config) {
+		c.registry = registry
+	}
+}
+
+// WithOperationID names the route on the OpenAPI document, it is also used
+// as the name of the method of the route on the generated clients, e.g.:
+//
+//	fiber.Get(app, "/users/:id", GetUser, kapi.WithRegistry(docs), kapi.WithOperationID("GetUser"))
+func WithOperationID(id string) Option {
+	return func(c *config) {
+		c.operationID = id
+	}
+}
@@ -0,0 +1,101 @@
+package kapi
+
+import (
+	"net"
+	"strings"
+)
+
+// The keys supported by the `request:"..."` tag, e.g.:
+//
+//	func(ctx *fiber.Ctx, args struct {
+//	  Method   string `request:"method"`
+//	  ClientIP string `request:"remote_ip"`
+//	}) error
+var requestInfoKeys = []string{
+	"method",
+	"path",
+	"host",
+	"scheme",
+	"remote_ip",
+	"user_agent",
+	"raw_query",
+	"request_uri",
+}
+
+func getRequestInfo(request RequestAdapter, key string, trustedProxies []*net.IPNet) string {
+	switch key {
+	case "method":
+		return request.GetMethod()
+	case "path":
+		return request.GetPath()
+	case "host":
+		return request.GetHost()
+	case "scheme":
+		return request.GetScheme()
+	case "remote_ip":
+		return getRemoteIP(request, trustedProxies)
+	case "user_agent":
+		return request.GetHeaderParam("User-Agent")
+	case "raw_query":
+		return request.GetRawQuery()
+	case "request_uri":
+		return request.GetRequestURI()
+	}
+
+	return ""
+}
+
+// getRemoteIP returns the IP of the client that made the request.
+//
+// If the direct peer is a trusted proxy the `X-Forwarded-For` header,
+// including all of its lines, is read from right to left skipping the
+// trusted proxies, the first IP that is not trusted is considered to be
+// the client IP.
+func getRemoteIP(request RequestAdapter, trustedProxies []*net.IPNet) string {
+	remoteIP := request.GetRemoteIP()
+	if len(trustedProxies) == 0 || !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	// Every line of the header is read since the proxies might append a new
+	// line instead of the last one, e.g. HAProxy with `option forwardfor`,
+	// in which case the first line would have been sent by the client:
+	var ips []string
+	request.VisitHeaders(func(key string, value string) {
+		if strings.EqualFold(key, "X-Forwarded-For") {
+			ips = append(ips, strings.Split(value, ",")...)
+		}
+	})
+	if len(ips) == 0 {
+		return remoteIP
+	}
+
+	for i := len(ips) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(ips[i])
+		if net.ParseIP(ip) == nil {
+			// Invalid IPs can't be trusted so we stop here:
+			return remoteIP
+		}
+
+		remoteIP = ip
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return remoteIP
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
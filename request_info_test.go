@@ -0,0 +1,170 @@
+package kapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	tt "github.com/vingarcia/kapi/internal/testtools"
+)
+
+func TestGetRemoteIP(t *testing.T) {
+	tests := []struct {
+		desc           string
+		trustedProxies []string
+		remoteIP       string
+		forwardedFor   string
+		expectedIP     string
+	}{
+		{
+			desc:         "should ignore X-Forwarded-For when no proxies are trusted",
+			remoteIP:     "10.0.0.1",
+			forwardedFor: "1.2.3.4",
+			expectedIP:   "10.0.0.1",
+		},
+		{
+			desc:           "should ignore X-Forwarded-For when the peer is not trusted",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "192.168.0.1",
+			forwardedFor:   "1.2.3.4",
+			expectedIP:     "192.168.0.1",
+		},
+		{
+			desc:           "should use the peer when the header is missing",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "10.0.0.1",
+			expectedIP:     "10.0.0.1",
+		},
+		{
+			desc:           "should read the client IP set by a trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "10.0.0.1",
+			forwardedFor:   "1.2.3.4",
+			expectedIP:     "1.2.3.4",
+		},
+		{
+			desc:           "should skip the trusted proxies from right to left",
+			trustedProxies: []string{"10.0.0.0/8", "172.16.0.5"},
+			remoteIP:       "10.0.0.1",
+			forwardedFor:   "1.2.3.4, 5.6.7.8, 172.16.0.5, 10.0.0.2",
+			expectedIP:     "5.6.7.8",
+		},
+		{
+			desc:           "should not trust IPs spoofed by the client on the left",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "10.0.0.1",
+			forwardedFor:   "10.0.0.3, 1.2.3.4",
+			expectedIP:     "1.2.3.4",
+		},
+		{
+			desc:           "should use the leftmost IP when every IP is trusted",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "10.0.0.1",
+			forwardedFor:   "10.0.0.3, 10.0.0.2",
+			expectedIP:     "10.0.0.3",
+		},
+		{
+			desc:           "should stop on invalid IPs",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteIP:       "10.0.0.1",
+			forwardedFor:   "1.2.3.4, not-an-ip, 10.0.0.2",
+			expectedIP:     "10.0.0.2",
+		},
+		{
+			desc:           "should support IPv6 proxies",
+			trustedProxies: []string{"::1"},
+			remoteIP:       "::1",
+			forwardedFor:   "2001:db8::1",
+			expectedIP:     "2001:db8::1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := newConfig([]Option{WithTrustedProxies(test.trustedProxies...)})
+			tt.AssertEqual(t, len(cfg.problems), 0)
+
+			headers := http.Header{}
+			if test.forwardedFor != "" {
+				headers.Set("X-Forwarded-For", test.forwardedFor)
+			}
+
+			ip := getRemoteIP(&fakeRequest{
+				remoteIP: test.remoteIP,
+				headers:  headers,
+			}, cfg.trustedProxies)
+			tt.AssertEqual(t, ip, test.expectedIP)
+		})
+	}
+}
+
+func TestGetRemoteIPWithMultipleHeaderLines(t *testing.T) {
+	cfg := newConfig([]Option{WithTrustedProxies("10.0.0.0/8")})
+	tt.AssertEqual(t, len(cfg.problems), 0)
+
+	t.Run("should read the lines appended by the proxies", func(t *testing.T) {
+		ip := getRemoteIP(&fakeRequest{
+			remoteIP: "10.0.0.1",
+			headers: http.Header{
+				// The first line was sent by the client:
+				"X-Forwarded-For": {"10.0.0.3", "1.2.3.4, 10.0.0.2"},
+			},
+		}, cfg.trustedProxies)
+		tt.AssertEqual(t, ip, "1.2.3.4")
+	})
+
+	t.Run("should continue on the previous lines", func(t *testing.T) {
+		ip := getRemoteIP(&fakeRequest{
+			remoteIP: "10.0.0.1",
+			headers: http.Header{
+				"X-Forwarded-For": {"1.2.3.4", "10.0.0.2"},
+			},
+		}, cfg.trustedProxies)
+		tt.AssertEqual(t, ip, "1.2.3.4")
+	})
+}
+
+func TestWithTrustedProxies(t *testing.T) {
+	t.Run("should report invalid proxies", func(t *testing.T) {
+		_, err := TryDecodeHandlerFunction(handlerType(struct{}{}), []reflect.Type{contextType},
+			WithTrustedProxies("10.0.0.1", "not-an-ip"),
+		)
+		tt.AssertErrContains(t, err, "invalid trusted proxy 'not-an-ip'")
+	})
+}
+
+func TestRequestTag(t *testing.T) {
+	type args struct {
+		Method     string `request:"method"`
+		Path       string `request:"path"`
+		Host       string `request:"host"`
+		Scheme     string `request:"scheme"`
+		RemoteIP   string `request:"remote_ip"`
+		UserAgent  string `request:"user_agent"`
+		RawQuery   string `request:"raw_query"`
+		RequestURI string `request:"request_uri"`
+	}
+
+	decoded, err := decodeArgs(&fakeRequest{
+		method:   "POST",
+		path:     "/users",
+		remoteIP: "10.0.0.1",
+		headers: http.Header{
+			"User-Agent":      {"fake-agent"},
+			"X-Forwarded-For": {"1.2.3.4"},
+		},
+		query: url.Values{"a": {"1"}},
+	}, args{}, WithTrustedProxies("10.0.0.0/8"))
+	tt.AssertNoErr(t, err)
+
+	tt.AssertEqual(t, decoded, args{
+		Method:     "POST",
+		Path:       "/users",
+		Host:       "localhost",
+		Scheme:     "http",
+		RemoteIP:   "1.2.3.4",
+		UserAgent:  "fake-agent",
+		RawQuery:   "a=1",
+		RequestURI: "/users?a=1",
+	})
+}
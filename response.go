@@ -0,0 +1,263 @@
+package kapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var cookieType = reflect.TypeOf(Cookie{})
+
+// WriteHandlerResponse receives the values returned by the user handler
+// and writes the response when the handler returns a value besides the error.
+//
+// The response value is encoded as JSON, unless it is a []byte in which case
+// it is sent as is with the "application/octet-stream" content type, and the
+// status is set to 200.
+//
+// If the response is a struct with fields tagged with `status`, `header` or `cookie`,
+// or with a field named `Body` tagged with `content-type`, these fields are used for
+// writing the response instead, and only the `Body` field is written as the body, e.g.:
+//
+//	type CreateUserResponse struct {
+//	  Status   int         `status:""`
+//	  Location string      `header:"Location"`
+//	  Session  kapi.Cookie `cookie:"sid"`
+//	  Body     User        `content-type:"application/json"`
+//	}
+//
+// If the error returned by the handler is not nil it is returned
+// and nothing is written so the framework can handle it.
+func WriteHandlerResponse(request RequestAdapter, funcInfo DecodedHandlerFunction, outputs []reflect.Value) error {
+	err, _ := outputs[len(outputs)-1].Interface().(error)
+	if err != nil || funcInfo.responseType == nil {
+		return err
+	}
+
+	return WriteResponseValue(request, funcInfo, outputs[0])
+}
+
+// WriteResponseValue writes the value returned by a handler as
+// described on WriteHandlerResponse, it is useful for adapters
+// that call the handler directly instead of using reflection.
+func WriteResponseValue(request RequestAdapter, funcInfo DecodedHandlerFunction, response reflect.Value) error {
+	if funcInfo.responseInfo != nil {
+		return writeResponseStruct(request, funcInfo.responseInfo, response)
+	}
+
+	contentType, body, err := encodeResponseBody(response, "")
+	if err != nil {
+		return request.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf(
+			"could not marshal response body: %s", err.Error(),
+		))
+	}
+
+	request.SetStatus(http.StatusOK)
+	request.SetHeader("Content-Type", contentType)
+	return request.WriteBody(body)
+}
+
+func writeResponseStruct(request RequestAdapter, info *responseInfo, response reflect.Value) error {
+	status := http.StatusOK
+	if info.statusIdx >= 0 {
+		if s := response.Field(info.statusIdx).Int(); s != 0 {
+			status = int(s)
+		}
+	}
+
+	var body []byte
+	if info.body != nil {
+		var contentType string
+		var err error
+		contentType, body, err = encodeResponseBody(response.FieldByIndex(info.body.Index), info.bodyContentType)
+		if err != nil {
+			return request.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf(
+				"could not marshal response body: %s", err.Error(),
+			))
+		}
+		request.SetHeader("Content-Type", contentType)
+	}
+
+	for _, header := range info.headers {
+		value := formatHeaderValue(response.FieldByIndex(header.Index))
+		if value == "" {
+			continue
+		}
+		request.SetHeader(header.Key, value)
+	}
+
+	for _, c := range info.cookies {
+		cookie, ok := buildCookie(c.Key, response.FieldByIndex(c.Index))
+		if !ok {
+			continue
+		}
+		request.SetCookie(cookie)
+	}
+
+	request.SetStatus(status)
+	if info.body == nil {
+		return nil
+	}
+	return request.WriteBody(body)
+}
+
+func encodeResponseBody(v reflect.Value, contentType string) (_ string, body []byte, _ error) {
+	if v.Type() == byteArrType {
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return contentType, v.Bytes(), nil
+	}
+
+	body, err := json.Marshal(v.Interface())
+	return "application/json", body, err
+}
+
+func formatHeaderValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+
+	return ""
+}
+
+func isHeaderKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func buildCookie(name string, v reflect.Value) (cookie Cookie, ok bool) {
+	switch {
+	case v.Kind() == reflect.String:
+		if v.String() == "" {
+			return Cookie{}, false
+		}
+		cookie.Value = v.String()
+	case v.Type() == cookieType:
+		cookie = v.Interface().(Cookie)
+		if cookie == (Cookie{}) {
+			return Cookie{}, false
+		}
+	case v.IsNil():
+		return Cookie{}, false
+	default:
+		cookie = v.Elem().Interface().(Cookie)
+	}
+
+	cookie.Name = name
+	return cookie, true
+}
+
+type responseInfo struct {
+	// statusIdx is -1 if there is no status field
+	statusIdx int
+
+	headers []tagInfo
+	cookies []tagInfo
+
+	bodyContentType string
+	body            *tagInfo
+}
+
+// getResponseInfo returns nil if the response type
+// doesn't use any of the response tags, in which case
+// the whole value should be encoded as the response body.
+func getResponseInfo(t reflect.Type) (_ *responseInfo, problems []string) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	info := responseInfo{
+		statusIdx: -1,
+	}
+	isResponseStruct := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if _, ok := field.Tag.Lookup("status"); ok {
+			isResponseStruct = true
+			if field.Type.Kind() != reflect.Int {
+				problems = append(problems, fmt.Sprintf(
+					"the status field of the response must be of type int, but %s is of type %v",
+					field.Name, field.Type,
+				))
+			}
+			info.statusIdx = i
+		}
+
+		if key := strings.Split(field.Tag.Get("header"), ",")[0]; key != "" {
+			isResponseStruct = true
+			if !isHeaderKind(field.Type.Kind()) {
+				problems = append(problems, fmt.Sprintf(
+					"response header fields must be strings or integers, but %s is of type %v",
+					field.Name, field.Type,
+				))
+			}
+			info.headers = append(info.headers, tagInfo{
+				Index: []int{i},
+				Name:  field.Name,
+				Key:   key,
+				Kind:  field.Type.Kind(),
+				Type:  field.Type,
+			})
+		}
+
+		if key := strings.Split(field.Tag.Get("cookie"), ",")[0]; key != "" {
+			isResponseStruct = true
+			if field.Type.Kind() != reflect.String && field.Type != cookieType && field.Type != reflect.PtrTo(cookieType) {
+				problems = append(problems, fmt.Sprintf(
+					"response cookie fields must be of type string, kapi.Cookie or *kapi.Cookie, but %s is of type %v",
+					field.Name, field.Type,
+				))
+			}
+			info.cookies = append(info.cookies, tagInfo{
+				Index: []int{i},
+				Name:  field.Name,
+				Key:   key,
+				Kind:  field.Type.Kind(),
+				Type:  field.Type,
+			})
+		}
+
+		if field.Name == "Body" {
+			// A field named Body alone is not enough since it might be just
+			// a field of the response value, e.g. the Body of a comment:
+			contentType, hasContentType := field.Tag.Lookup("content-type")
+			if hasContentType {
+				isResponseStruct = true
+			}
+			info.bodyContentType = strings.Split(contentType, ",")[0]
+			if info.bodyContentType != "" && info.bodyContentType != "application/json" && field.Type != byteArrType {
+				problems = append(problems, fmt.Sprintf(
+					"mimetype '%s' is not supported yet for the response field %s",
+					info.bodyContentType, field.Name,
+				))
+			}
+			info.body = &tagInfo{
+				Index: []int{i},
+				Name:  field.Name,
+				Kind:  field.Type.Kind(),
+				Type:  field.Type,
+			}
+		}
+	}
+
+	if !isResponseStruct {
+		return nil, problems
+	}
+
+	return &info, problems
+}
@@ -0,0 +1,113 @@
+package kapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CheckRouteParams checks that every path param used on the handler's args struct
+// is present on the list of params of the route it is being registered on.
+//
+// This function is meant to be used by the adapters during the route registration,
+// each adapter is responsible for parsing the route pattern since their syntax differ,
+// e.g. "/users/:id" on fiber and "/users/<id>" on fasthttp-routing.
+//
+// If any param is missing a *HandlerError is returned listing all of them.
+func CheckRouteParams(funcInfo DecodedHandlerFunction, routePattern string, routeParams []string) error {
+	available := map[string]bool{}
+	for _, param := range routeParams {
+		available[param] = true
+	}
+
+	var problems []string
+	for _, key := range sortedKeys(funcInfo.pathParams) {
+		if available[key] {
+			continue
+		}
+
+		problems = append(problems, fmt.Sprintf(
+			"field %s reads the path param '%s' but the route '%s' has no such param",
+			funcInfo.pathParams[key].Name, key, routePattern,
+		))
+	}
+
+	if len(problems) > 0 {
+		return newHandlerError(funcInfo.handlerType, problems)
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]tagInfo) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Registry collects the routes registered by the adapters, so they can be
+// described by an OpenAPI document, see Registry.OpenAPI.
+//
+// Routes are only added to a registry when they are registered with
+// the route helpers of the adapters, e.g. `fiber.Get()`, using the
+// WithRegistry option:
+//
+//	docs := kapi.NewRegistry()
+//	fiber.Get(app, "/users/:id", GetUser, kapi.WithRegistry(docs))
+type Registry struct {
+	mu     sync.Mutex
+	routes []RegisteredRoute
+}
+
+// RegisteredRoute describes one of the routes of a Registry
+type RegisteredRoute struct {
+	Method string
+
+	// Path uses the OpenAPI syntax for params, e.g. "/users/{id}"
+	Path string
+
+	Handler DecodedHandlerFunction
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a route to the registry, the path must use
+// the OpenAPI syntax for params, e.g. "/users/{id}".
+func (r *Registry) Register(method string, path string, funcInfo DecodedHandlerFunction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.routes = append(r.routes, RegisteredRoute{
+		Method:  strings.ToUpper(method),
+		Path:    path,
+		Handler: funcInfo,
+	})
+}
+
+// Routes returns the routes of the registry in the order they were registered
+func (r *Registry) Routes() []RegisteredRoute {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RegisteredRoute(nil), r.routes...)
+}
+
+// RegisterRoute adds the route to the Registry informed with the WithRegistry
+// option when the handler was adapted, if no registry was informed it does nothing.
+//
+// This function is meant to be used by the adapters during the route registration,
+// after converting the route pattern to the OpenAPI syntax, e.g. "/users/{id}".
+func RegisterRoute(funcInfo DecodedHandlerFunction, method string, path string) {
+	if funcInfo.registry == nil {
+		return
+	}
+
+	funcInfo.registry.Register(method, path, funcInfo)
+}